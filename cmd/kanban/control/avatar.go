@@ -0,0 +1,40 @@
+package control
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/util"
+)
+
+// Avatar renders a person as their initials inside a coloured circle.
+type Avatar struct {
+	Initials string
+	Color    color.NRGBA
+	Size     unit.Value
+}
+
+func (a Avatar) Layout(gtx C, th *material.Theme) D {
+	sz := gtx.Px(a.Size)
+	gtx.Constraints = layout.Exact(image.Pt(sz, sz))
+	return layout.Stack{Alignment: layout.Center}.Layout(
+		gtx,
+		layout.Expanded(func(gtx C) D {
+			return util.Rect{
+				Color: a.Color,
+				Size:  layout.FPt(gtx.Constraints.Min),
+				Radii: float32(sz) / 2,
+			}.Layout(gtx)
+		}),
+		layout.Stacked(func(gtx C) D {
+			gtx.Constraints.Min = image.Point{}
+			l := material.Label(th, a.Size.Scale(0.45), a.Initials)
+			l.Color = Contrast(a.Color)
+			l.MaxLines = 1
+			return l.Layout(gtx)
+		}),
+	)
+}
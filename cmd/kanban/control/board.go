@@ -0,0 +1,173 @@
+package control
+
+import (
+	"image"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+// Board lays out panels side by side and allows tickets to be dragged between
+// them.
+//
+// Pointer events are handled by the board rather than by the tickets, since a
+// drag that starts in one panel can end in any other. Geometry recorded by the
+// panels during layout is used to resolve pointer positions into slots.
+type Board struct {
+	// from is the slot of the ticket being dragged.
+	from Slot
+	// press is where the pointer was pressed.
+	press f32.Point
+	// pos is the last known pointer position.
+	pos      f32.Point
+	pressed  bool
+	dragging bool
+	// width of each panel from the last layout.
+	width int
+	drops []Drop
+}
+
+// Slot identifies a ticket position within a panel.
+type Slot struct {
+	Panel int
+	Index int
+}
+
+// Drop describes a ticket that was dragged from one slot and dropped into
+// another.
+// The destination index is the position the ticket should occupy after it is
+// moved.
+type Drop struct {
+	From Slot
+	To   Slot
+}
+
+// Dropped reports whether a ticket has been dropped. If so, Dropped removes the
+// earliest drop.
+func (b *Board) Dropped() (Drop, bool) {
+	if len(b.drops) == 0 {
+		return Drop{}, false
+	}
+	d := b.drops[0]
+	b.drops = b.drops[1:]
+	return d, true
+}
+
+// Dragging reports whether a ticket is being dragged.
+func (b *Board) Dragging() bool {
+	return b.dragging
+}
+
+// Layout the panels with equal widths.
+// Tickets returns the list elements for the panel at the given index.
+func (b *Board) Layout(
+	gtx C,
+	th *material.Theme,
+	panels []*Panel,
+	tickets func(panel int) []layout.ListElement,
+) D {
+	if len(panels) == 0 {
+		return D{Size: gtx.Constraints.Min}
+	}
+	b.update(gtx, panels)
+	b.width = gtx.Constraints.Max.X / len(panels)
+	for ii, p := range panels {
+		p.dropping = false
+		if b.dragging && b.panelAt(b.pos) == ii {
+			p.dropping = true
+			p.drop = p.gapAt(int(b.pos.Y))
+		}
+	}
+	defer op.Save(gtx.Ops).Load()
+	pointer.Rect(image.Rectangle{Max: gtx.Constraints.Max}).Add(gtx.Ops)
+	pointer.InputOp{
+		Tag:   b,
+		Grab:  b.dragging,
+		Types: pointer.Press | pointer.Drag | pointer.Release,
+	}.Add(gtx.Ops)
+	if b.dragging {
+		pointer.CursorNameOp{Name: pointer.CursorGrab}.Add(gtx.Ops)
+	}
+	for ii, p := range panels {
+		stack := op.Save(gtx.Ops)
+		op.Offset(f32.Point{X: float32(ii * b.width)}).Add(gtx.Ops)
+		gtx := gtx
+		gtx.Constraints = layout.Exact(image.Point{
+			X: b.width,
+			Y: gtx.Constraints.Max.Y,
+		})
+		p.Layout(gtx, th, tickets(ii)...)
+		stack.Load()
+	}
+	return D{Size: gtx.Constraints.Max}
+}
+
+// update processes pointer events.
+func (b *Board) update(gtx C, panels []*Panel) {
+	for _, event := range gtx.Events(b) {
+		e, ok := event.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch e.Type {
+		case pointer.Press:
+			if e.Source == pointer.Mouse && !e.Buttons.Contain(pointer.ButtonPrimary) {
+				continue
+			}
+			panel := b.panelAt(e.Position)
+			if panel < 0 || panel > len(panels)-1 {
+				continue
+			}
+			if index, ok := panels[panel].itemAt(int(e.Position.Y)); ok {
+				b.from = Slot{Panel: panel, Index: index}
+				b.press = e.Position
+				b.pos = e.Position
+				b.pressed = true
+			}
+		case pointer.Drag:
+			if !b.pressed {
+				continue
+			}
+			b.pos = e.Position
+			if d := e.Position.Sub(b.press); !b.dragging &&
+				d.X*d.X+d.Y*d.Y > float32(gtx.Px(unit.Dp(10))*gtx.Px(unit.Dp(10))) {
+				b.dragging = true
+			}
+		case pointer.Release:
+			if b.dragging {
+				if panel := b.panelAt(e.Position); panel >= 0 && panel < len(panels) {
+					to := Slot{
+						Panel: panel,
+						Index: panels[panel].gapAt(int(e.Position.Y)),
+					}
+					// Account for the ticket being removed from above the
+					// insertion point.
+					if to.Panel == b.from.Panel && to.Index > b.from.Index {
+						to.Index--
+					}
+					if to != b.from {
+						b.drops = append(b.drops, Drop{From: b.from, To: to})
+						op.InvalidateOp{}.Add(gtx.Ops)
+					}
+				}
+			}
+			b.pressed = false
+			b.dragging = false
+		case pointer.Cancel:
+			b.pressed = false
+			b.dragging = false
+		}
+	}
+}
+
+// panelAt returns the index of the panel at the given position.
+func (b *Board) panelAt(pos f32.Point) int {
+	if b.width == 0 || pos.X < 0 {
+		return -1
+	}
+	return int(pos.X) / b.width
+}
@@ -0,0 +1,153 @@
+package control
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/f32"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+)
+
+// StackedArea plots series stacked on top of each other, such as for a
+// cumulative flow diagram.
+//
+// The first series sits at the bottom of the stack. Every series is expected
+// to hold the same number of points, plotted evenly across the width.
+type StackedArea struct {
+	Series [][]int
+	Colors []color.NRGBA
+	Height unit.Value
+}
+
+func (a StackedArea) Layout(gtx C) D {
+	var (
+		sz     = image.Pt(gtx.Constraints.Max.X, gtx.Px(a.Height))
+		points = 0
+	)
+	for _, s := range a.Series {
+		if len(s) > points {
+			points = len(s)
+		}
+	}
+	// totals holds the running height of the stack at each point.
+	var (
+		totals = make([]int, points)
+		tops   = make([][]int, len(a.Series))
+	)
+	for ii, s := range a.Series {
+		tops[ii] = make([]int, points)
+		for jj := range totals {
+			if jj < len(s) {
+				totals[jj] += s[jj]
+			}
+			tops[ii][jj] = totals[jj]
+		}
+	}
+	peak := 0
+	for _, total := range totals {
+		if total > peak {
+			peak = total
+		}
+	}
+	defer axis(gtx, sz)
+	if points == 0 || peak == 0 {
+		return D{Size: sz}
+	}
+	x := func(ii int) float32 {
+		if points == 1 {
+			return float32(ii * sz.X)
+		}
+		return float32(ii*sz.X) / float32(points-1)
+	}
+	y := func(v int) float32 {
+		return float32(sz.Y) - float32(v*sz.Y)/float32(peak)
+	}
+	// A single point is drawn as a band across the full width.
+	last := points - 1
+	if points == 1 {
+		last = 1
+	}
+	at := func(row []int, ii int) int {
+		if ii >= len(row) {
+			return row[len(row)-1]
+		}
+		return row[ii]
+	}
+	// Paint from the top of the stack down so that each band covers only the
+	// area between its own top and the band beneath it.
+	for ii := len(tops) - 1; ii >= 0; ii-- {
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		p.MoveTo(f32.Pt(x(0), y(0)))
+		for jj := 0; jj <= last; jj++ {
+			p.LineTo(f32.Pt(x(jj), y(at(tops[ii], jj))))
+		}
+		p.LineTo(f32.Pt(x(last), y(0)))
+		p.Close()
+		paint.FillShape(gtx.Ops, a.color(ii), clip.Outline{Path: p.End()}.Op())
+	}
+	return D{Size: sz}
+}
+
+func (a StackedArea) color(ii int) color.NRGBA {
+	if len(a.Colors) == 0 {
+		return color.NRGBA{A: 255}
+	}
+	return a.Colors[ii%len(a.Colors)]
+}
+
+// Bars plots values as vertical bars, evenly spaced across the width.
+type Bars struct {
+	Values []int
+	Color  color.NRGBA
+	Height unit.Value
+	// Gap between adjacent bars.
+	Gap unit.Value
+}
+
+func (b Bars) Layout(gtx C) D {
+	sz := image.Pt(gtx.Constraints.Max.X, gtx.Px(b.Height))
+	peak := 0
+	for _, v := range b.Values {
+		if v > peak {
+			peak = v
+		}
+	}
+	defer axis(gtx, sz)
+	if len(b.Values) == 0 || peak == 0 {
+		return D{Size: sz}
+	}
+	var (
+		gap   = float32(gtx.Px(b.Gap))
+		slot  = float32(sz.X) / float32(len(b.Values))
+		width = slot - gap
+	)
+	if width < 1 {
+		width = 1
+	}
+	for ii, v := range b.Values {
+		if v == 0 {
+			continue
+		}
+		var (
+			left = float32(ii)*slot + gap/2
+			top  = float32(sz.Y) - float32(v*sz.Y)/float32(peak)
+		)
+		paint.FillShape(gtx.Ops, b.Color, clip.UniformRRect(
+			f32.Rect(left, top, left+width, float32(sz.Y)),
+			0,
+		).Op(gtx.Ops))
+	}
+	return D{Size: sz}
+}
+
+// axis draws a faint baseline along the bottom of a chart.
+func axis(gtx C, sz image.Point) {
+	thickness := gtx.Px(unit.Dp(1))
+	paint.FillShape(gtx.Ops, color.NRGBA{A: 80}, clip.Rect{
+		Min: image.Pt(0, sz.Y-thickness),
+		Max: sz,
+	}.Op())
+}
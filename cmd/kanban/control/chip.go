@@ -0,0 +1,61 @@
+package control
+
+import (
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/util"
+)
+
+// Chip implements "https://material.io/components/chips".
+//
+// Displays a short label on a rounded background of the given colour.
+type Chip struct {
+	Label string
+	Color color.NRGBA
+	// Faded renders the chip translucent, such as for an unselected option.
+	Faded bool
+}
+
+func (c Chip) Layout(gtx C, th *material.Theme) D {
+	bg := c.Color
+	fg := Contrast(bg)
+	if c.Faded {
+		bg.A = 60
+		fg = th.Fg
+	}
+	return layout.Stack{}.Layout(
+		gtx,
+		layout.Expanded(func(gtx C) D {
+			return util.Rect{
+				Color: bg,
+				Size:  layout.FPt(gtx.Constraints.Min),
+				Radii: float32(gtx.Px(unit.Dp(8))),
+			}.Layout(gtx)
+		}),
+		layout.Stacked(func(gtx C) D {
+			return layout.Inset{
+				Top:    unit.Dp(2),
+				Bottom: unit.Dp(2),
+				Left:   unit.Dp(8),
+				Right:  unit.Dp(8),
+			}.Layout(gtx, func(gtx C) D {
+				l := material.Caption(th, c.Label)
+				l.Color = fg
+				l.MaxLines = 1
+				return l.Layout(gtx)
+			})
+		}),
+	)
+}
+
+// Contrast returns black or white, whichever is more legible against bg.
+func Contrast(bg color.NRGBA) color.NRGBA {
+	// Perceived luminance, weighted per ITU-R BT.601.
+	if 299*int(bg.R)+587*int(bg.G)+114*int(bg.B) > 150*1000 {
+		return color.NRGBA{A: 255}
+	}
+	return color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+}
@@ -1,15 +1,18 @@
 package control
 
 import (
+	"fmt"
 	"image/color"
 
 	"gioui.org/f32"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
 	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/util"
 	"git.sr.ht/~jackmordaunt/kanban/icons"
+	"github.com/google/uuid"
 )
 
 type (
@@ -17,16 +20,89 @@ type (
 	D = layout.Dimensions
 )
 
+// WarningColor highlights a panel holding more tickets than its limit.
+var WarningColor = color.NRGBA{R: 230, G: 80, B: 60, A: 255}
+
 // Panel can hold cards.
 // One panel per stage in the kanban pipeline.
 // Has a title and action bar.
 type Panel struct {
-	Label        string
-	Color        color.NRGBA
+	// ID of the stage rendered by the panel.
+	ID    uuid.UUID
+	Label string
+	Color color.NRGBA
+	// Limit is the number of tickets the panel should hold, zero for no
+	// limit.
+	Limit int
+	// Count is the number of tickets in the stage, which is more than the
+	// tickets laid out while the board is filtered.
+	Count int
+	// Exceeded turns the header WarningColor, for a stage holding more
+	// tickets than its limit.
+	Exceeded     bool
 	Thickness    unit.Value
 	CreateTicket widget.Clickable
 
 	layout.List
+
+	// dropping is true while a ticket is being dragged over the panel.
+	dropping bool
+	// drop is the insertion point at which to render a drop indicator.
+	drop int
+	// header is the height of the title bar from the last layout.
+	header int
+	// items records the vertical extents of the visible tickets from the last
+	// layout, in list coordinates.
+	items []span
+	sizes []int
+}
+
+// span is the vertical extent of a list item.
+type span struct {
+	Index int
+	Top   int
+	Bot   int
+}
+
+// itemAt returns the index of the ticket under the vertical position y, relative
+// to the top of the panel.
+func (p *Panel) itemAt(y int) (int, bool) {
+	y -= p.header
+	for _, s := range p.items {
+		if y >= s.Top && y < s.Bot {
+			return s.Index, true
+		}
+	}
+	return 0, false
+}
+
+// gapAt returns the insertion point nearest the vertical position y, relative
+// to the top of the panel.
+func (p *Panel) gapAt(y int) int {
+	y -= p.header
+	for _, s := range p.items {
+		if y < (s.Top+s.Bot)/2 {
+			return s.Index
+		}
+	}
+	if len(p.items) > 0 {
+		return p.items[len(p.items)-1].Index + 1
+	}
+	return 0
+}
+
+// gapOffset returns the vertical position of the insertion point, in list
+// coordinates.
+func (p *Panel) gapOffset(gap int) int {
+	for _, s := range p.items {
+		if s.Index == gap {
+			return s.Top
+		}
+	}
+	if len(p.items) > 0 {
+		return p.items[len(p.items)-1].Bot
+	}
+	return 0
 }
 
 func (p *Panel) Layout(gtx C, th *material.Theme, tickets ...layout.ListElement) D {
@@ -39,7 +115,7 @@ func (p *Panel) Layout(gtx C, th *material.Theme, tickets ...layout.ListElement)
 		}.Layout(
 			gtx,
 			layout.Rigid(func(gtx C) D {
-				return layout.Stack{}.Layout(
+				dims := layout.Stack{}.Layout(
 					gtx,
 					layout.Expanded(func(gtx C) D {
 						return util.Rect{
@@ -47,7 +123,12 @@ func (p *Panel) Layout(gtx C, th *material.Theme, tickets ...layout.ListElement)
 								X: layout.FPt(gtx.Constraints.Max).X,
 								Y: float32(gtx.Px(p.Thickness)),
 							},
-							Color: p.Color,
+							Color: func() color.NRGBA {
+								if p.Exceeded {
+									return WarningColor
+								}
+								return p.Color
+							}(),
 						}.Layout(gtx)
 					}),
 					layout.Stacked(func(gtx C) D {
@@ -64,6 +145,14 @@ func (p *Panel) Layout(gtx C, th *material.Theme, tickets ...layout.ListElement)
 								layout.Rigid(func(gtx C) D {
 									return material.H6(th, p.Label).Layout(gtx)
 								}),
+								layout.Rigid(func(gtx C) D {
+									if p.Limit < 1 {
+										return D{}
+									}
+									return layout.Inset{Left: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+										return material.Body1(th, fmt.Sprintf("%d/%d", p.Count, p.Limit)).Layout(gtx)
+									})
+								}),
 								layout.Flexed(1, func(gtx C) D {
 									return D{Size: gtx.Constraints.Min}
 								}),
@@ -81,6 +170,8 @@ func (p *Panel) Layout(gtx C, th *material.Theme, tickets ...layout.ListElement)
 						})
 					}),
 				)
+				p.header = dims.Size.Y
+				return dims
 			}),
 			layout.Flexed(1, func(gtx C) D {
 				return layout.Stack{}.Layout(
@@ -93,12 +184,41 @@ func (p *Panel) Layout(gtx C, th *material.Theme, tickets ...layout.ListElement)
 					}),
 					layout.Stacked(func(gtx C) D {
 						p.List.Axis = layout.Vertical
-						return p.List.Layout(gtx, len(tickets), func(gtx C, ii int) D {
-							return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx C) D {
+						if cap(p.sizes) < len(tickets) {
+							p.sizes = make([]int, len(tickets))
+						}
+						p.sizes = p.sizes[:len(tickets)]
+						dims := p.List.Layout(gtx, len(tickets), func(gtx C, ii int) D {
+							dims := layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx C) D {
 								return tickets[ii](gtx, ii)
 							})
-
+							p.sizes[ii] = dims.Size.Y
+							return dims
 						})
+						p.items = p.items[:0]
+						y := -p.List.Position.Offset
+						for ii := p.List.Position.First; ii < p.List.Position.First+p.List.Position.Count; ii++ {
+							p.items = append(p.items, span{Index: ii, Top: y, Bot: y + p.sizes[ii]})
+							y += p.sizes[ii]
+						}
+						return dims
+					}),
+					layout.Expanded(func(gtx C) D {
+						if !p.dropping {
+							return D{}
+						}
+						defer op.Save(gtx.Ops).Load()
+						thickness := gtx.Px(unit.Dp(3))
+						op.Offset(f32.Point{
+							Y: float32(p.gapOffset(p.drop) - thickness/2),
+						}).Add(gtx.Ops)
+						return util.Rect{
+							Color: color.NRGBA{B: 200, A: 200},
+							Size: f32.Point{
+								X: float32(gtx.Constraints.Max.X),
+								Y: float32(thickness),
+							},
+						}.Layout(gtx)
 					}),
 				)
 			}),
@@ -0,0 +1,90 @@
+package control
+
+import (
+	"image/color"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/util"
+)
+
+// Snackbar implements "https://material.io/components/snackbars".
+//
+// Displays a short message with an optional action, hiding itself after a
+// timeout.
+type Snackbar struct {
+	Message string
+	Label   string
+	Action  widget.Clickable
+	until   time.Time
+}
+
+// Show the message with an action labelled label, for the given duration.
+// An empty label renders no action.
+func (s *Snackbar) Show(message, label string, d time.Duration) {
+	s.Message = message
+	s.Label = label
+	s.until = time.Now().Add(d)
+}
+
+// Hide the snackbar immediately.
+func (s *Snackbar) Hide() {
+	s.until = time.Time{}
+}
+
+// Layout the snackbar along the bottom of the available space.
+func (s *Snackbar) Layout(gtx C, th *material.Theme) D {
+	if !gtx.Now.Before(s.until) {
+		return D{}
+	}
+	op.InvalidateOp{At: s.until}.Add(gtx.Ops)
+	return layout.S.Layout(gtx, func(gtx C) D {
+		return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx C) D {
+			return layout.Stack{}.Layout(
+				gtx,
+				layout.Expanded(func(gtx C) D {
+					return util.Rect{
+						Color: color.NRGBA{R: 50, G: 50, B: 50, A: 255},
+						Size:  layout.FPt(gtx.Constraints.Min),
+						Radii: 4,
+					}.Layout(gtx)
+				}),
+				layout.Stacked(func(gtx C) D {
+					return layout.Inset{
+						Left:  unit.Dp(15),
+						Right: unit.Dp(5),
+					}.Layout(gtx, func(gtx C) D {
+						return layout.Flex{
+							Axis:      layout.Horizontal,
+							Alignment: layout.Middle,
+						}.Layout(
+							gtx,
+							layout.Rigid(func(gtx C) D {
+								l := material.Body1(th, s.Message)
+								l.Color = th.ContrastFg
+								return layout.Inset{
+									Top:    unit.Dp(14),
+									Bottom: unit.Dp(14),
+									Right:  unit.Dp(10),
+								}.Layout(gtx, l.Layout)
+							}),
+							layout.Rigid(func(gtx C) D {
+								if s.Label == "" {
+									return D{}
+								}
+								btn := material.Button(th, &s.Action, s.Label)
+								btn.Background = color.NRGBA{}
+								btn.Color = color.NRGBA{R: 150, G: 180, B: 255, A: 255}
+								return btn.Layout(gtx)
+							}),
+						)
+					})
+				}),
+			)
+		})
+	})
+}
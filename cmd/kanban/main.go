@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/user"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/profile"
 	"github.com/spf13/pflag"
@@ -14,18 +17,24 @@ import (
 	"gioui.org/unit"
 	"gioui.org/widget/material"
 	"git.sr.ht/~jackmordaunt/kanban/storage"
+	"git.sr.ht/~jackmordaunt/kanban/storage/bolt"
 	"git.sr.ht/~jackmordaunt/kanban/storage/lazy"
+	"git.sr.ht/~jackmordaunt/kanban/storage/remote"
 
 	"gioui.org/app"
 )
 
 var (
 	MemStorage bool
+	Remote     string
 	ProfileOpt string
+	User       string
 )
 
 func init() {
 	pflag.BoolVar(&MemStorage, "mem-storage", false, "store entities in memory")
+	pflag.StringVar(&Remote, "remote", "", "store entities on the kanban server at this URL, such as one run by \"kanbanctl serve\"")
+	pflag.StringVar(&User, "user", currentUser(), "name to identify as amongst project people")
 	pflag.StringVar(&ProfileOpt, "profile", "", fmt.Sprintf("record runtime performance statistics %s", profiles))
 	pflag.Parse()
 }
@@ -35,13 +44,22 @@ func main() {
 		defer stopper.Stop()
 	}
 	storage, err := func() (storage.Storer, error) {
+		if Remote != "" {
+			return remote.New(Remote), nil
+		}
 		data, err := app.DataDir()
 		if err != nil {
 			return nil, fmt.Errorf("data dir: %v", err)
 		}
 		db := filepath.Join(data, "kanban.db")
 		fmt.Printf("%s\n", db)
-		return lazy.Open(db)
+		s, err := lazy.Open(db)
+		if errors.Is(err, bolt.ErrLocked) {
+			// Only one instance can have the file open, so that instances
+			// cannot save over each other.
+			return nil, fmt.Errorf("%s is open in another instance of kanban: close it first, or share projects between instances with \"kanbanctl serve\" and --remote", db)
+		}
+		return s, err
 	}()
 	if err != nil {
 		log.Fatalf("storage driver: %v\n", err)
@@ -54,6 +72,10 @@ func main() {
 			Window:  app.NewWindow(app.Title("Kanban"), app.MinSize(unit.Dp(700), unit.Dp(250))),
 			Th:      material.NewTheme(gofont.Collection()),
 			Storage: storage,
+			User:    User,
+		}
+		if r, ok := storage.(*remote.Storer); ok {
+			r.Watch(5*time.Second, ui.Window.Invalidate, nil)
 		}
 		if err := ui.Loop(); err != nil {
 			log.Fatalf("error: %v", err)
@@ -63,6 +85,17 @@ func main() {
 	app.Main()
 }
 
+// currentUser returns the name of the user running the program, if known.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		if u.Name != "" {
+			return u.Name
+		}
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
 // Profile starts a profiler based on the provided option.
 type Profile string
 
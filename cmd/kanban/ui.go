@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"log"
+	"strings"
+	"time"
 	"unsafe"
 
 	"gioui.org/app"
@@ -21,6 +24,8 @@ import (
 	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/control"
 	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/state"
 	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/util"
+	"git.sr.ht/~jackmordaunt/kanban/focus"
+	"git.sr.ht/~jackmordaunt/kanban/history"
 	"git.sr.ht/~jackmordaunt/kanban/icons"
 	"git.sr.ht/~jackmordaunt/kanban/storage"
 	"github.com/google/uuid"
@@ -50,6 +55,10 @@ type UI struct {
 	// Storage driver responsible for allocating Project objects.
 	Storage storage.Storer
 
+	// User is the name of the person using the app, matched against the
+	// people of each project.
+	User string
+
 	// Projects is an in-memory list of the projects.
 	// Refreshed from Storage before every frame.
 	// Save to Storage after every frame.
@@ -69,6 +78,9 @@ type UI struct {
 	// Shares the same lifetime as the active project.
 	Panels []*control.Panel
 
+	// Board lays out the Panels and allows tickets to be dragged between them.
+	Board control.Board
+
 	// Rail allows intra-project navigation as a side bar.
 	// When a Project item is clicked, that Project is loaded from storage and
 	// becomes the active Project.
@@ -86,17 +98,64 @@ type UI struct {
 	TicketDetails              TicketDetails
 	DeleteDialog               DeleteDialog
 	ProjectForm                ProjectForm
+	DeleteStageDialog          DeleteStageDialog
 	ArchiveProjectConfirmation ArchiveProjectConfirmation
+	FinalizedArchive           FinalizedArchive
+	ArchivedProjects           ArchivedProjects
+	DeleteProjectConfirmation  DeleteProjectConfirmation
+	Stats                      Stats
+	Charts                     Charts
+
+	// History records the commands applied to each project so that they can
+	// be undone.
+	History map[uuid.UUID]*history.History
+
+	// saved holds each project as it was last loaded or saved, so that the
+	// changes lost to a conflict can be named.
+	saved map[uuid.UUID]kanban.Project
+
+	// Snackbar displays transient messages, such as the offer to undo a
+	// destructive action.
+	Snackbar control.Snackbar
 
 	// Focus tracks the focused ticket for keyboard navigation.
-	Focus struct {
-		Stage  int
-		Ticket int
-		T      *kanban.Ticket
-	}
+	Focus focus.Focus
+
+	// Filter selects which tickets are shown on the board.
+	Filter Filter
 
 	CreateProjectBtn widget.Clickable
 	EditProjectBtn   widget.Clickable
+	FinalizedBtn     widget.Clickable
+	ArchivedBtn      widget.Clickable
+	DueSoonBtn       widget.Clickable
+	MineBtn          widget.Clickable
+	StatsBtn         widget.Clickable
+	ChartsBtn        widget.Clickable
+}
+
+// Filter selects which tickets are shown on the board.
+// The zero value shows every ticket.
+type Filter struct {
+	// DueSoon shows only tickets that are overdue or fall due soon.
+	DueSoon bool
+	// Soon holds the tickets that are overdue or fall due soon.
+	Soon map[uuid.UUID]bool
+	// Mine shows only tickets assigned to Me.
+	Mine bool
+	// Me identifies the user amongst the people of the project.
+	Me uuid.UUID
+}
+
+// Match reports whether the ticket passes the filter.
+func (f Filter) Match(t kanban.Ticket) bool {
+	if f.DueSoon && !f.Soon[t.ID] {
+		return false
+	}
+	if f.Mine && !t.AssignedTo(f.Me) {
+		return false
+	}
+	return true
 }
 
 // Loop runs the event loop until terminated.
@@ -109,6 +168,7 @@ func (ui *UI) Loop() error {
 	if err := ui.Storage.Load(ui.Projects); err != nil {
 		return fmt.Errorf("loading projects: %w", err)
 	}
+	ui.remember()
 	if len(ui.Projects) > 0 {
 		ui.Project = &ui.Projects[0]
 	}
@@ -147,6 +207,12 @@ func (ui *UI) Update(gtx C) {
 		if k, ok := event.(key.Event); ok {
 			if k.State == key.Press {
 				switch k.Name {
+				case "Z":
+					if k.Modifiers.Contain(key.ModCtrl | key.ModShift) {
+						ui.Redo()
+					} else if k.Modifiers.Contain(key.ModCtrl) {
+						ui.Undo()
+					}
 				case key.NameEscape:
 					ui.Clear()
 				case key.NameEnter, key.NameReturn:
@@ -154,9 +220,17 @@ func (ui *UI) Update(gtx C) {
 						ui.EditTicket(*t)
 					}
 				case key.NameDownArrow:
-					ui.Refocus(NextTicket)
+					if k.Modifiers.Contain(key.ModCtrl) {
+						ui.ShiftFocused(kanban.Forward, k.Modifiers.Contain(key.ModShift))
+					} else {
+						ui.Refocus(NextTicket)
+					}
 				case key.NameUpArrow:
-					ui.Refocus(PreviousTicket)
+					if k.Modifiers.Contain(key.ModCtrl) {
+						ui.ShiftFocused(kanban.Backward, k.Modifiers.Contain(key.ModShift))
+					} else {
+						ui.Refocus(PreviousTicket)
+					}
 				case key.NameRightArrow:
 					ui.Refocus(NextStage)
 				case key.NameLeftArrow:
@@ -165,32 +239,102 @@ func (ui *UI) Update(gtx C) {
 			}
 		}
 	}
-	if ui.ProjectForm.SubmitBtn.Clicked() {
+	if ui.ProjectForm.SubmitBtn.Clicked() && ui.ProjectForm.Validate() {
 		if ui.ProjectForm.Mode() == ModeEdit {
+			before := ui.Project.Clone()
 			ui.ProjectForm.Submit()
+			after := ui.Project.Clone()
+			*ui.Project = before
+			if err := ui.Do(&history.EditProject{
+				Before: history.SettingsOf(before),
+				After:  history.SettingsOf(after),
+			}); err != nil {
+				log.Printf("editing project: %v", err)
+			} else if len(after.Stages) < len(before.Stages) {
+				ui.Snackbar.Show("Deleted stages", "Undo", 5*time.Second)
+			}
+			ui.sync()
 		}
 		if ui.ProjectForm.Mode() == ModeCreate {
-			if err := ui.Storage.Create(kanban.Project{
-				ID:   uuid.New(),
-				Name: ui.ProjectForm.Name.Text(),
-				Stages: []kanban.Stage{
-					{Name: "Todo"},
-					{Name: "In Progress"},
-					{Name: "Testing"},
-					{Name: "Done"},
-				},
-			}); err != nil {
+			ui.ProjectForm.Submit()
+		}
+		if ui.ProjectForm.Mode() == ModeCreate {
+			project := ui.ProjectForm.Draft
+			project.ID = uuid.New()
+			if err := ui.Storage.Create(project); err != nil {
 				log.Printf("creating new project: %v", err)
 			} else {
-				if projects, err := ui.Storage.List(); err == nil {
-					ui.Projects = projects
-				} else {
-					log.Printf("listing projects: %v", err)
-				}
+				ui.Refresh()
 			}
 		}
 		ui.Clear()
 	}
+	if ui.ProjectForm.AddStage.Clicked() {
+		ui.ProjectForm.NewStage()
+	}
+	for ii, field := range ui.ProjectForm.StageFields {
+		if field.Up.Clicked() {
+			ui.ProjectForm.MoveStage(ii, kanban.Backward)
+			break
+		}
+		if field.Down.Clicked() {
+			ui.ProjectForm.MoveStage(ii, kanban.Forward)
+			break
+		}
+		if field.Delete.Clicked() {
+			if len(ui.ProjectForm.Draft.Stages[ii].Tickets) > 0 {
+				ui.ShowDeleteStageDialog(ii)
+			} else if err := ui.ProjectForm.RemoveStage(ii, -1); err != nil {
+				log.Printf("removing stage: %v", err)
+			}
+			break
+		}
+	}
+	if ui.ProjectForm.AddLabel.Clicked() {
+		ui.ProjectForm.NewLabel(fmt.Sprintf("Label %d", len(ui.ProjectForm.LabelFields)+1))
+		ui.ProjectForm.LabelFields[len(ui.ProjectForm.LabelFields)-1].Name.Focus()
+	}
+	for ii, field := range ui.ProjectForm.LabelFields {
+		if field.Color.Clicked() {
+			ui.ProjectForm.CycleLabel(ii)
+			break
+		}
+		if field.Delete.Clicked() {
+			ui.ProjectForm.RemoveLabel(ii)
+			break
+		}
+	}
+	if id, ok := clicked(&ui.TicketForm.LabelToggles); ok {
+		ui.TicketForm.ToggleLabel(id)
+	}
+	if ui.ProjectForm.AddPerson.Clicked() {
+		ui.ProjectForm.NewPerson("")
+		ui.ProjectForm.PersonFields[len(ui.ProjectForm.PersonFields)-1].Name.Focus()
+	}
+	for ii, field := range ui.ProjectForm.PersonFields {
+		if field.Delete.Clicked() {
+			ui.ProjectForm.RemovePerson(ii)
+			break
+		}
+	}
+	if id, ok := clicked(&ui.TicketForm.AssigneeToggles); ok {
+		ui.TicketForm.ToggleAssignee(id)
+	}
+	if ui.Project != nil {
+		ui.TicketForm.UpdateBlockers(ui.Project)
+	}
+	for ii := range ui.DeleteStageDialog.Targets {
+		if ui.DeleteStageDialog.Targets[ii].Clicked() {
+			if err := ui.ProjectForm.RemoveStage(ui.DeleteStageDialog.Stage, ii); err != nil {
+				ui.Snackbar.Show(fmt.Sprintf("Cannot delete stage: %v", err), "", 5*time.Second)
+			}
+			ui.ShowProjectForm()
+			break
+		}
+	}
+	if ui.DeleteStageDialog.Cancel.Clicked() {
+		ui.ShowProjectForm()
+	}
 	// @CLEANUP(jfm): Unclear code. If no active project or the selected
 	// project does not match active project, make the selected project the
 	// active project.
@@ -212,8 +356,24 @@ func (ui *UI) Update(gtx C) {
 	for ii := range ui.Panels {
 		panel := ui.Panels[ii]
 		if panel.CreateTicket.Clicked() {
-			ui.AddTicket(panel.Label)
+			ui.AddTicket(panel.ID)
+		}
+	}
+	for drop, ok := ui.Board.Dropped(); ok; drop, ok = ui.Board.Dropped() {
+		if ui.Project == nil || drop.From.Panel >= len(ui.Project.Stages) || drop.To.Panel >= len(ui.Project.Stages) {
+			continue
 		}
+		from := ui.visible(ui.Project.Stages[drop.From.Panel])
+		if drop.From.Index >= len(from) {
+			continue
+		}
+		var (
+			stage = ui.Project.Stages[drop.To.Panel].ID
+			index = ui.index(drop.To)
+		)
+		ui.MoveTicket(from[drop.From.Index], func(p *kanban.Project, t kanban.Ticket) error {
+			return p.MoveTicketToStage(t, stage, index)
+		})
 	}
 	for ui.TicketStates.More() {
 		_, v := ui.TicketStates.Next()
@@ -222,10 +382,16 @@ func (ui *UI) Update(gtx C) {
 			continue
 		}
 		if t.NextButton.Clicked() {
-			ui.Project.ProgressTicket(t.Ticket)
+			ui.MoveTicket(t.Ticket, (*kanban.Project).ProgressTicket)
 		}
 		if t.PrevButton.Clicked() {
-			ui.Project.RegressTicket(t.Ticket)
+			ui.MoveTicket(t.Ticket, (*kanban.Project).RegressTicket)
+		}
+		if t.UpButton.Clicked() {
+			ui.ShiftTicket(t.Ticket, kanban.Backward, false)
+		}
+		if t.DownButton.Clicked() {
+			ui.ShiftTicket(t.Ticket, kanban.Forward, false)
 		}
 		if t.EditButton.Clicked() {
 			ui.EditTicket(t.Ticket)
@@ -237,14 +403,20 @@ func (ui *UI) Update(gtx C) {
 			ui.InspectTicket(t.Ticket)
 		}
 	}
-	if ui.TicketForm.SubmitBtn.Clicked() {
+	if ui.TicketForm.SubmitBtn.Clicked() && ui.TicketForm.Validate() {
 		t := ui.TicketForm.Submit()
 		if t.ID == uuid.Nil {
-			if err := ui.Project.AssignTicket(ui.TicketForm.Stage, t); err != nil {
+			if err := ui.Do(&history.CreateTicket{
+				Stage:  ui.TicketForm.Stage,
+				Ticket: t,
+			}); err != nil {
 				log.Printf("assigning ticket: %v", err)
 			}
-		} else {
-			if err := ui.Project.UpdateTicket(t); err != nil {
+		} else if before, ok := ui.Project.FindTicket(t.ID); ok {
+			if err := ui.Do(&history.EditTicket{
+				Before: before,
+				After:  t,
+			}); err != nil {
 				log.Printf("updating ticket: %v", err)
 			}
 		}
@@ -254,12 +426,31 @@ func (ui *UI) Update(gtx C) {
 		ui.Clear()
 	}
 	if ui.DeleteDialog.Ok.Clicked() {
-		ui.Project.FinalizeTicket(ui.DeleteDialog.Ticket)
+		if err := ui.Do(&history.FinalizeTicket{Ticket: ui.DeleteDialog.Ticket}); err != nil {
+			log.Printf("finalizing ticket: %v", err)
+			ui.Snackbar.Show(fmt.Sprintf("Cannot finalize: %v", err), "", 5*time.Second)
+		} else {
+			ui.Snackbar.Show(fmt.Sprintf("Deleted %q", ui.DeleteDialog.Title), "Undo", 5*time.Second)
+		}
 		ui.Clear()
 	}
 	if ui.DeleteDialog.Cancel.Clicked() {
 		ui.Clear()
 	}
+	if ui.Snackbar.Action.Clicked() {
+		ui.Undo()
+		ui.Snackbar.Hide()
+	}
+	if after, ok := ui.TicketDetails.Changed(ui.User); ok {
+		if err := ui.Do(&history.EditTicket{
+			Before: ui.TicketDetails.Ticket,
+			After:  after,
+		}); err != nil {
+			log.Printf("updating ticket: %v", err)
+		} else {
+			ui.TicketDetails.Ticket = after
+		}
+	}
 	if ui.TicketDetails.Edit.Clicked() {
 		ui.EditTicket(ui.TicketDetails.Ticket)
 	}
@@ -272,6 +463,44 @@ func (ui *UI) Update(gtx C) {
 	if ui.EditProjectBtn.Clicked() {
 		ui.EditProject()
 	}
+	if ui.FinalizedBtn.Clicked() {
+		ui.ShowFinalized()
+	}
+	if ui.DueSoonBtn.Clicked() {
+		ui.Filter.DueSoon = !ui.Filter.DueSoon
+		ui.Focus.Clear()
+	}
+	if ui.MineBtn.Clicked() {
+		ui.Filter.Mine = !ui.Filter.Mine
+		ui.Focus.Clear()
+		if _, ok := ui.me(); ui.Filter.Mine && !ok {
+			ui.Snackbar.Show(fmt.Sprintf("Add %q to the project's people to see your tickets", ui.User), "", 4*time.Second)
+		}
+	}
+	if id, ok := ui.FinalizedArchive.Restored(); ok && ui.Project != nil {
+		if err := ui.Do(&history.RestoreTicket{
+			Ticket: kanban.Ticket{ID: id},
+			Stage:  ui.FinalizedArchive.Into(),
+		}); err != nil {
+			log.Printf("restoring ticket: %v", err)
+			ui.Snackbar.Show(fmt.Sprintf("Cannot restore: %v", err), "", 5*time.Second)
+		}
+	}
+	if ui.StatsBtn.Clicked() {
+		ui.ShowStats()
+	}
+	if ui.Stats.Close.Clicked() {
+		ui.Clear()
+	}
+	if ui.ChartsBtn.Clicked() {
+		ui.ShowCharts()
+	}
+	if ui.Charts.Close.Clicked() {
+		ui.Clear()
+	}
+	if ui.FinalizedArchive.Close.Clicked() {
+		ui.Clear()
+	}
 	if ui.ProjectForm.Delete.Button.Clicked() {
 		ui.ShowArchiveProjectConfirmation()
 	}
@@ -280,16 +509,48 @@ func (ui *UI) Update(gtx C) {
 			if err := ui.Storage.Archive(ui.Project.ID); err != nil {
 				log.Printf("error: archiving project: %v", err)
 			}
-			ui.Save()
-			if len(ui.Projects) > 0 {
-				ui.Project = &ui.Projects[0]
-			}
+			ui.Refresh()
 			ui.Clear()
 		}
 	}
 	if ui.ArchiveProjectConfirmation.CancelBtn.Clicked() {
 		ui.Clear()
 	}
+	if ui.ArchivedBtn.Clicked() {
+		ui.ShowArchived()
+	}
+	if id, ok := ui.ArchivedProjects.Restored(); ok {
+		if err := ui.Storage.Restore(id); err != nil {
+			log.Printf("error: restoring project: %v", err)
+		}
+		ui.Refresh()
+		if p, ok := ui.Projects.Find(id); ok {
+			ui.Project = p
+		}
+		ui.Clear()
+	}
+	if id, ok := ui.ArchivedProjects.Deleted(); ok {
+		for _, p := range ui.ArchivedProjects.Projects {
+			if p.ID == id {
+				ui.ShowDeleteProjectConfirmation(p)
+			}
+		}
+	}
+	if ui.ArchivedProjects.Close.Clicked() {
+		ui.Clear()
+	}
+	if ui.DeleteProjectConfirmation.SubmitBtn.Clicked() {
+		p := ui.DeleteProjectConfirmation.Project
+		if ui.DeleteProjectConfirmation.Confirmation.Text() == p.Name {
+			if err := ui.Storage.Delete(p.ID); err != nil {
+				log.Printf("error: deleting project: %v", err)
+			}
+			ui.ShowArchived()
+		}
+	}
+	if ui.DeleteProjectConfirmation.CancelBtn.Clicked() {
+		ui.ShowArchived()
+	}
 }
 
 // Layout UI.
@@ -362,12 +623,28 @@ func (ui *UI) layoutRail(gtx C) D {
 	return ui.Rail.Layout(
 		gtx,
 		func(gtx C) D {
-			return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx C) D {
-				btn := material.IconButton(ui.Th, &ui.CreateProjectBtn, icons.ContentAdd)
-				btn.Size = unit.Dp(20)
-				btn.Inset = layout.UniformInset(unit.Dp(8))
-				return btn.Layout(gtx)
-			})
+			return layout.Flex{
+				Axis:      layout.Vertical,
+				Alignment: layout.Middle,
+			}.Layout(
+				gtx,
+				layout.Rigid(func(gtx C) D {
+					return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx C) D {
+						btn := material.IconButton(ui.Th, &ui.CreateProjectBtn, icons.ContentAdd)
+						btn.Size = unit.Dp(20)
+						btn.Inset = layout.UniformInset(unit.Dp(8))
+						return btn.Layout(gtx)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx C) D {
+						btn := material.IconButton(ui.Th, &ui.ArchivedBtn, icons.Archive)
+						btn.Size = unit.Dp(20)
+						btn.Inset = layout.UniformInset(unit.Dp(8))
+						return btn.Layout(gtx)
+					})
+				}),
+			)
 		},
 		rc...,
 	)
@@ -412,6 +689,42 @@ func (ui *UI) layoutContent(gtx C) D {
 							layout.Flexed(1, func(gtx C) D {
 								return D{Size: image.Point{X: gtx.Constraints.Max.X, Y: gtx.Constraints.Min.Y}}
 							}),
+							layout.Rigid(func(gtx C) D {
+								btn := material.IconButton(ui.Th, &ui.MineBtn, icons.Person)
+								btn.Background = color.NRGBA{}
+								if ui.Filter.Mine {
+									btn.Background = ui.Th.ContrastBg
+								}
+								btn.Inset = layout.UniformInset(unit.Dp(5))
+								return btn.Layout(gtx)
+							}),
+							layout.Rigid(func(gtx C) D {
+								btn := material.IconButton(ui.Th, &ui.DueSoonBtn, icons.Schedule)
+								btn.Background = color.NRGBA{}
+								if ui.Filter.DueSoon {
+									btn.Background = ui.Th.ContrastBg
+								}
+								btn.Inset = layout.UniformInset(unit.Dp(5))
+								return btn.Layout(gtx)
+							}),
+							layout.Rigid(func(gtx C) D {
+								btn := material.IconButton(ui.Th, &ui.StatsBtn, icons.Chart)
+								btn.Background = color.NRGBA{}
+								btn.Inset = layout.UniformInset(unit.Dp(5))
+								return btn.Layout(gtx)
+							}),
+							layout.Rigid(func(gtx C) D {
+								btn := material.IconButton(ui.Th, &ui.ChartsBtn, icons.Flow)
+								btn.Background = color.NRGBA{}
+								btn.Inset = layout.UniformInset(unit.Dp(5))
+								return btn.Layout(gtx)
+							}),
+							layout.Rigid(func(gtx C) D {
+								btn := material.IconButton(ui.Th, &ui.FinalizedBtn, icons.Archive)
+								btn.Background = color.NRGBA{}
+								btn.Inset = layout.UniformInset(unit.Dp(5))
+								return btn.Layout(gtx)
+							}),
 							layout.Rigid(func(gtx C) D {
 								btn := material.IconButton(ui.Th, &ui.EditProjectBtn, icons.Configuration)
 								btn.Background = color.NRGBA{}
@@ -431,41 +744,34 @@ func (ui *UI) layoutContent(gtx C) D {
 						return D{}
 					}
 					ui.TicketStates.Begin()
-					return layout.Flex{
-						Axis:    layout.Horizontal,
-						Spacing: layout.SpaceEvenly,
-					}.Layout(
-						gtx,
-						func() (panels []layout.FlexChild) {
-							if len(ui.Panels) == 0 {
-								return panels
-							}
-							// @decouple this iteration relies on the coincidence that panels are ordered the same.
-							for ii, stage := range ui.Project.Stages {
-								stage := stage
-								panel := ui.Panels[ii]
-								panels = append(panels, layout.Flexed(1, func(gtx C) D {
-									return panel.Layout(gtx, ui.Th, func() (tickets []layout.ListElement) {
-										for ii := range stage.Tickets {
-											ticket := stage.Tickets[ii]
-											t := (*Ticket)(ui.TicketStates.New(ticket.ID.String(), unsafe.Pointer(&Ticket{})))
-											t.Ticket = ticket
-											t.Stage = stage.Name
-											tickets = append(tickets, func(gtx C, index int) D {
-												var focused bool
-												if ui.Focus.T != nil && ui.Focus.T.ID == t.ID {
-													focused = true
-												}
-												return t.Layout(gtx, ui.Th, focused)
-											})
-										}
-										return tickets
-									}()...)
-								}))
-							}
-							return panels
-						}()...,
-					)
+					// @decouple this iteration relies on the coincidence that panels are ordered the same.
+					return ui.Board.Layout(gtx, ui.Th, ui.Panels, func(ii int) (tickets []layout.ListElement) {
+						if ii > len(ui.Project.Stages)-1 {
+							return nil
+						}
+						stage := ui.Project.Stages[ii]
+						// The panel counts every ticket in the stage, not just
+						// those that pass the filter.
+						ui.Panels[ii].Count = len(stage.Tickets)
+						ui.Panels[ii].Exceeded = stage.Exceeded()
+						for _, ticket := range ui.visible(stage) {
+							t := (*Ticket)(ui.TicketStates.New(ticket.ID.String(), unsafe.Pointer(&Ticket{})))
+							t.Ticket = ticket
+							t.Stage = stage.ID
+							t.Chips = ui.Project.LabelsFor(ticket)
+							t.Avatars = ui.Project.AssigneesFor(ticket)
+							t.Blocked = ui.Project.Blocked(ticket)
+							t.Stale = stage.Stale(ticket, gtx.Now)
+							tickets = append(tickets, func(gtx C, index int) D {
+								var focused bool
+								if ui.Focus.T != nil && ui.Focus.T.ID == t.ID {
+									focused = true
+								}
+								return t.Layout(gtx, ui.Th, focused)
+							})
+						}
+						return tickets
+					})
 				}),
 				layout.Expanded(func(gtx C) D {
 					if ui.Modal == nil {
@@ -475,68 +781,191 @@ func (ui *UI) layoutContent(gtx C) D {
 						return ui.Modal(gtx)
 					})
 				}),
+				layout.Expanded(func(gtx C) D {
+					return ui.Snackbar.Layout(gtx, ui.Th)
+				}),
 			)
 		}),
 	)
 }
 
-type Direction uint8
+// Direction aliases focus.Direction, so that keyboard handling reads
+// naturally.
+type Direction = focus.Direction
 
 const (
-	NextTicket Direction = iota
-	PreviousTicket
-	NextStage
-	PreviousStage
+	NextTicket     = focus.NextTicket
+	PreviousTicket = focus.PreviousTicket
+	NextStage      = focus.NextStage
+	PreviousStage  = focus.PreviousStage
 )
 
 // Refocus to the ticket in the given direction.
-// Allows movement between tickets and stages in sequential order.
-//
-// Stages and Tickets form a 2D array, so we can simply iterate over that with
-// an index for each dimension.
+// See focus.Focus.Move for the semantics, which are shared with the terminal
+// board.
+// Tickets hidden by the board filter are skipped.
 func (ui *UI) Refocus(d Direction) {
-	if len(ui.Project.Stages) == 0 {
+	ui.Focus.Visible = ui.shown()
+	ui.Focus.Move(ui.Project, d)
+}
+
+// ShiftFocused moves the focused ticket within its stage, keeping it focused.
+// Backward moves towards the top of the stage and Forward towards the bottom.
+// If extreme is true the ticket is moved all the way to the top or bottom.
+func (ui *UI) ShiftFocused(dir kanban.Direction, extreme bool) {
+	if ui.Project == nil || ui.Focus.T == nil {
 		return
 	}
-	if ui.Focus.T == nil {
-		if stage := &ui.Project.Stages[ui.Focus.Stage]; len(stage.Tickets) > 0 {
-			ui.Focus.T = &stage.Tickets[ui.Focus.Ticket]
-		}
+	t := *ui.Focus.T
+	ui.ShiftTicket(t, dir, extreme)
+	ui.FocusTicket(t)
+}
+
+// ShiftTicket moves a ticket within its stage.
+// Backward moves towards the top of the stage and Forward towards the bottom.
+// If extreme is true the ticket is moved all the way to the top or bottom.
+func (ui *UI) ShiftTicket(t kanban.Ticket, dir kanban.Direction, extreme bool) {
+	if err := ui.Do(&history.MoveTicket{
+		Ticket: t,
+		Move: func(p *kanban.Project, t kanban.Ticket) error {
+			switch {
+			case extreme && dir == kanban.Backward:
+				p.MoveTicketToTop(t)
+			case extreme:
+				p.MoveTicketToBottom(t)
+			default:
+				p.MoveTicket(t, dir)
+			}
+			return nil
+		},
+	}); err != nil {
+		log.Printf("moving ticket: %v", err)
+	}
+}
+
+// FocusTicket moves focus to the given ticket, wherever it sits.
+func (ui *UI) FocusTicket(t kanban.Ticket) {
+	ui.Focus.To(ui.Project, t)
+}
+
+// Do applies a command to the active project, recording it so that it can be
+// undone.
+func (ui *UI) Do(c history.Command) error {
+	if ui.Project == nil {
+		return nil
+	}
+	return ui.history().Do(ui.Project, c)
+}
+
+// Undo the last command applied to the active project.
+func (ui *UI) Undo() {
+	if ui.Project == nil {
 		return
 	}
-	switch d {
-	case NextTicket:
-		ui.Focus.Ticket++
-		if ui.Focus.Ticket > len(ui.Project.Stages[ui.Focus.Stage].Tickets)-1 {
-			ui.Focus.Ticket = 0
-		}
-	case PreviousTicket:
-		ui.Focus.Ticket--
-		if ui.Focus.Ticket < 0 {
-			ui.Focus.Ticket = len(ui.Project.Stages[ui.Focus.Stage].Tickets) - 1
-		}
-	}
-	if len(ui.Project.Stages) > 1 {
-		switch d {
-		case NextStage:
-			ui.Focus.Stage++
-			ui.Focus.Ticket = 0
-			if ui.Focus.Stage > len(ui.Project.Stages)-1 {
-				ui.Focus.Stage = 0
-			}
-		case PreviousStage:
-			ui.Focus.Stage--
-			ui.Focus.Ticket = 0
-			if ui.Focus.Stage < 0 {
-				ui.Focus.Stage = len(ui.Project.Stages) - 1
-			}
+	if err := ui.history().Undo(ui.Project); err != nil {
+		log.Printf("error: %v", err)
+	}
+	ui.Focus.Clear()
+	if len(ui.Panels) != len(ui.Project.Stages) {
+		ui.sync()
+	}
+}
+
+// Redo the last command undone in the active project.
+func (ui *UI) Redo() {
+	if ui.Project == nil {
+		return
+	}
+	if err := ui.history().Redo(ui.Project); err != nil {
+		log.Printf("error: %v", err)
+	}
+	ui.Focus.Clear()
+	if len(ui.Panels) != len(ui.Project.Stages) {
+		ui.sync()
+	}
+}
+
+// history returns the history for the active project.
+func (ui *UI) history() *history.History {
+	if ui.History == nil {
+		ui.History = make(map[uuid.UUID]*history.History)
+	}
+	h, ok := ui.History[ui.Project.ID]
+	if !ok {
+		h = &history.History{}
+		ui.History[ui.Project.ID] = h
+	}
+	return h
+}
+
+// MoveTicket moves a ticket between stages, reporting refused moves and
+// warning when a blocked ticket is let past the gate.
+func (ui *UI) MoveTicket(t kanban.Ticket, move func(*kanban.Project, kanban.Ticket) error) {
+	past := ui.Project.PastGate(t)
+	if err := ui.Do(&history.MoveTicket{Ticket: t, Move: move}); err != nil {
+		log.Printf("moving ticket: %v", err)
+		ui.Snackbar.Show(fmt.Sprintf("Cannot move: %v", err), "", 5*time.Second)
+		return
+	}
+	if t, ok := ui.Project.FindTicket(t.ID); ok && !past && ui.Project.PastGate(t) && ui.Project.Blocked(t) {
+		ui.Snackbar.Show(fmt.Sprintf("%q is still blocked", t.Title), "Undo", 5*time.Second)
+	}
+}
+
+// visible returns the tickets in the stage that pass the board filter.
+func (ui *UI) visible(s kanban.Stage) []kanban.Ticket {
+	var (
+		tickets []kanban.Ticket
+		shown   = ui.shown()
+	)
+	for _, t := range s.Tickets {
+		if shown(t) {
+			tickets = append(tickets, t)
+		}
+	}
+	return tickets
+}
+
+// shown returns a func reporting whether a ticket passes the board filter.
+func (ui *UI) shown() func(kanban.Ticket) bool {
+	filter := ui.Filter
+	if me, ok := ui.me(); ok {
+		filter.Me = me.ID
+	}
+	if filter.DueSoon && ui.Project != nil {
+		filter.Soon = soon(ui.Project, time.Now())
+	}
+	return func(t kanban.Ticket) bool {
+		return filter.Match(t)
+	}
+}
+
+// me returns the user's entry in the people of the active project.
+func (ui *UI) me() (kanban.Person, bool) {
+	if ui.Project == nil {
+		return kanban.Person{}, false
+	}
+	return ui.Project.FindPersonByName(ui.User)
+}
+
+// index maps a slot on the filtered board to an index into the stage's full
+// list of tickets.
+func (ui *UI) index(slot control.Slot) int {
+	var (
+		stage   = ui.Project.Stages[slot.Panel]
+		visible = ui.visible(stage)
+	)
+	if slot.Index < len(visible) {
+		if ii, ok := stage.Index(visible[slot.Index]); ok {
+			return ii
 		}
 	}
-	if stage := &ui.Project.Stages[ui.Focus.Stage]; len(stage.Tickets) == 0 {
-		ui.Refocus(d)
-	} else {
-		ui.Focus.T = &stage.Tickets[ui.Focus.Ticket]
+	if len(visible) > 0 {
+		if ii, ok := stage.Index(visible[len(visible)-1]); ok {
+			return ii + 1
+		}
 	}
+	return len(stage.Tickets)
 }
 
 // Clear resets navigational state.
@@ -544,15 +973,23 @@ func (ui *UI) Clear() {
 	ui.Modal = nil
 	ui.TicketForm = TicketForm{}
 	ui.ProjectForm = ProjectForm{}
+	ui.DeleteStageDialog = DeleteStageDialog{}
 	ui.DeleteDialog = DeleteDialog{}
 	ui.ArchiveProjectConfirmation = ArchiveProjectConfirmation{}
+	ui.FinalizedArchive = FinalizedArchive{}
+	ui.Stats = Stats{}
+	ui.Charts = Charts{}
+	ui.ArchivedProjects = ArchivedProjects{}
+	ui.DeleteProjectConfirmation = DeleteProjectConfirmation{}
 }
 
 // InspectTicket opens the ticket details card for the given ticket.
 func (ui *UI) InspectTicket(t kanban.Ticket) {
 	ui.TicketDetails.Ticket = t
+	ui.TicketDetails.Editing = uuid.Nil
+	ui.TicketDetails.NewComment.SetText("")
 	ui.Modal = func(gtx C) D {
-		return ui.TicketDetails.Layout(gtx, ui.Th)
+		return ui.TicketDetails.Layout(gtx, ui.Th, ui.Project, ui.User)
 	}
 }
 
@@ -560,15 +997,15 @@ func (ui *UI) InspectTicket(t kanban.Ticket) {
 func (ui *UI) EditTicket(t kanban.Ticket) {
 	ui.TicketForm.Edit(t)
 	ui.Modal = func(gtx C) D {
-		return ui.TicketForm.Layout(gtx, ui.Th, "")
+		return ui.TicketForm.Layout(gtx, ui.Th, uuid.Nil, ui.Project)
 	}
 }
 
 // AddTicket opens the ticket form for creating ticket data.
-func (ui *UI) AddTicket(stage string) {
+func (ui *UI) AddTicket(stage uuid.UUID) {
 	ui.TicketForm.Title.Focus()
 	ui.Modal = func(gtx C) D {
-		return ui.TicketForm.Layout(gtx, ui.Th, stage)
+		return ui.TicketForm.Layout(gtx, ui.Th, stage, ui.Project)
 	}
 }
 
@@ -582,10 +1019,12 @@ func (ui *UI) DeleteTicket(t kanban.Ticket) {
 
 // CreateProject opens the project creation dialog.
 func (ui *UI) CreateProject() {
-	ui.ProjectForm.Name.Focus()
-	ui.Modal = func(gtx C) D {
-		return ui.ProjectForm.Layout(gtx, ui.Th)
+	ui.ProjectForm.Create("Todo", "In Progress", "Testing", "Done")
+	for _, name := range []string{"Bug", "Feature", "Chore"} {
+		ui.ProjectForm.NewLabel(name)
 	}
+	ui.ProjectForm.Name.Focus()
+	ui.ShowProjectForm()
 }
 
 // EditProject opens the project edit form.
@@ -595,11 +1034,86 @@ func (ui *UI) EditProject() {
 	}
 	ui.ProjectForm.Edit(ui.Project)
 	ui.ProjectForm.Name.Focus()
+	ui.ShowProjectForm()
+}
+
+// ShowProjectForm displays the project form in its current state.
+func (ui *UI) ShowProjectForm() {
+	ui.DeleteStageDialog = DeleteStageDialog{}
 	ui.Modal = func(gtx C) D {
 		return ui.ProjectForm.Layout(gtx, ui.Th)
 	}
 }
 
+// ShowDeleteStageDialog prompts for where to move the tickets of the stage
+// being deleted from the project form.
+func (ui *UI) ShowDeleteStageDialog(stage int) {
+	ui.DeleteStageDialog = DeleteStageDialog{Stage: stage}
+	ui.Modal = func(gtx C) D {
+		return ui.DeleteStageDialog.Layout(
+			gtx,
+			ui.Th,
+			ui.ProjectForm.Draft.Stages,
+			ui.ProjectForm.StageNames(),
+		)
+	}
+}
+
+// ShowFinalized opens the archive of finalized tickets for the active project.
+func (ui *UI) ShowFinalized() {
+	if ui.Project == nil {
+		return
+	}
+	ui.FinalizedArchive.Search.Focus()
+	ui.Modal = func(gtx C) D {
+		return ui.FinalizedArchive.Layout(gtx, ui.Th, ui.Project)
+	}
+}
+
+// ShowStats opens the flow metrics of the active project.
+func (ui *UI) ShowStats() {
+	if ui.Project == nil {
+		return
+	}
+	ui.Modal = func(gtx C) D {
+		return ui.Stats.Layout(gtx, ui.Th, ui.Project)
+	}
+}
+
+// ShowCharts opens the cumulative flow and throughput charts of the active
+// project.
+func (ui *UI) ShowCharts() {
+	if ui.Project == nil {
+		return
+	}
+	ui.Modal = func(gtx C) D {
+		return ui.Charts.Layout(gtx, ui.Th, ui.Project)
+	}
+}
+
+// ShowArchived opens the list of archived projects.
+func (ui *UI) ShowArchived() {
+	archived, err := ui.Storage.ListArchived()
+	if err != nil {
+		log.Printf("error: listing archived projects: %v", err)
+	}
+	ui.DeleteProjectConfirmation = DeleteProjectConfirmation{}
+	ui.ArchivedProjects.Projects = archived
+	ui.Modal = func(gtx C) D {
+		return ui.ArchivedProjects.Layout(gtx, ui.Th)
+	}
+}
+
+// ShowDeleteProjectConfirmation opens the dialog confirming permanent deletion
+// of an archived project.
+func (ui *UI) ShowDeleteProjectConfirmation(p kanban.Project) {
+	ui.DeleteProjectConfirmation = DeleteProjectConfirmation{Project: p}
+	ui.Modal = func(gtx C) D {
+		return ui.DeleteProjectConfirmation.Layout(gtx, ui.Th)
+	}
+	ui.DeleteProjectConfirmation.Confirmation.Focus()
+}
+
 func (ui *UI) ShowArchiveProjectConfirmation() {
 	if ui.Project == nil {
 		return
@@ -626,16 +1140,86 @@ func (plist Projects) Find(id uuid.UUID) (*kanban.Project, bool) {
 
 // Load entities from storage.
 func (ui *UI) Load() {
+	revisions := make(map[uuid.UUID]uint64, len(ui.Projects))
+	for _, p := range ui.Projects {
+		revisions[p.ID] = p.Revision
+	}
 	if err := ui.Storage.Load(ui.Projects); err != nil {
 		log.Printf("error: loading projects: %v", err)
 	}
+	// Saving keeps revisions in step, so a project loaded at a different
+	// revision was changed elsewhere, and its undo history no longer applies.
+	for _, p := range ui.Projects {
+		if rev, ok := revisions[p.ID]; ok && rev != p.Revision {
+			delete(ui.History, p.ID)
+			if ui.Project != nil && ui.Project.ID == p.ID {
+				ui.Focus.Clear()
+				if len(ui.Panels) != len(ui.Project.Stages) {
+					ui.sync()
+				}
+			}
+		}
+	}
+	ui.remember()
+}
+
+// Refresh the list of projects from storage.
+// The active project stays active if it still exists, otherwise the first
+// project becomes active.
+func (ui *UI) Refresh() {
+	projects, err := ui.Storage.List()
+	if err != nil {
+		log.Printf("error: listing projects: %v", err)
+		return
+	}
+	var active uuid.UUID
+	if ui.Project != nil {
+		active = ui.Project.ID
+	}
+	ui.Projects = projects
+	ui.Project = nil
+	if p, ok := ui.Projects.Find(active); ok {
+		ui.Project = p
+	} else if len(ui.Projects) > 0 {
+		ui.Project = &ui.Projects[0]
+	}
+	ui.remember()
+}
+
+// remember the projects that were loaded or saved at a new revision.
+func (ui *UI) remember() {
+	if ui.saved == nil {
+		ui.saved = make(map[uuid.UUID]kanban.Project)
+	}
+	for _, p := range ui.Projects {
+		if saved, ok := ui.saved[p.ID]; !ok || saved.Revision != p.Revision {
+			ui.saved[p.ID] = p.Clone()
+		}
+	}
 }
 
 // Save entities to storage.
+//
+// A project that was changed elsewhere since it was loaded is reloaded,
+// discarding the local changes and their undo history, since saving them
+// would overwrite someone else's work. The user is told which tickets the
+// discarded changes touched.
 func (ui *UI) Save() {
-	if err := ui.Storage.Save(ui.Projects...); err != nil {
+	var conflict storage.ConflictError
+	if err := ui.Storage.Save(ui.Projects...); errors.As(err, &conflict) {
+		msg := fmt.Sprintf("%q was changed elsewhere and has been reloaded", conflict.Name)
+		if p, ok := ui.Projects.Find(conflict.ID); ok {
+			if lost := unsaved(ui.saved[p.ID], *p); len(lost) > 0 {
+				msg += ", discarding your changes to " + strings.Join(lost, ", ")
+			}
+		}
+		delete(ui.History, conflict.ID)
+		ui.Refresh()
+		ui.Snackbar.Show(msg, "", 10*time.Second)
+	} else if err != nil {
 		log.Printf("error: saving projects: %v", err)
 	}
+	ui.remember()
 	// Remove any zeroed out projects because they don't exist anymore.
 	for ii, p := range ui.Projects {
 		if p.ID == uuid.Nil {
@@ -646,28 +1230,118 @@ func (ui *UI) Save() {
 	}
 }
 
+// unsaved names the changes made to a project since it was saved: the tickets
+// created, edited, moved or deleted, the stages reordered, and the settings.
+func unsaved(before, after kanban.Project) []string {
+	var (
+		lost  []string
+		was   = make(map[uuid.UUID]kanban.Ticket)
+		where = make(map[uuid.UUID]uuid.UUID)
+		is    = make(map[uuid.UUID]bool)
+	)
+	for _, s := range before.Stages {
+		for _, t := range s.Tickets {
+			was[t.ID], where[t.ID] = t, s.ID
+		}
+	}
+	for _, t := range before.Finalized {
+		was[t.ID] = t
+	}
+	check := func(t kanban.Ticket, stage uuid.UUID) {
+		is[t.ID] = true
+		if w, ok := was[t.ID]; !ok || !w.Eq(t) || where[t.ID] != stage {
+			lost = append(lost, fmt.Sprintf("%q", t.Title))
+		}
+	}
+	for _, s := range after.Stages {
+		for _, t := range s.Tickets {
+			check(t, s.ID)
+		}
+		if old := before.Stages.Find(s.ID); old != nil && !sameOrder(old.Tickets, s.Tickets) {
+			lost = append(lost, fmt.Sprintf("the order of %q", s.Name))
+		}
+	}
+	for _, t := range after.Finalized {
+		check(t, uuid.Nil)
+	}
+	for _, t := range was {
+		if !is[t.ID] {
+			lost = append(lost, fmt.Sprintf("%q", t.Title))
+		}
+	}
+	// Whatever is left once the tickets are set aside is the settings.
+	before, after = before.Clone(), after.Clone()
+	for _, p := range []*kanban.Project{&before, &after} {
+		for ii := range p.Stages {
+			p.Stages[ii].Tickets = nil
+		}
+		p.Finalized = nil
+	}
+	if !before.Eq(&after) {
+		lost = append(lost, "the project settings")
+	}
+	return lost
+}
+
+// sameOrder reports whether the tickets common to both lists are in the same
+// order in each.
+func sameOrder(a, b []kanban.Ticket) bool {
+	in := make(map[uuid.UUID]bool, len(b))
+	for _, t := range b {
+		in[t.ID] = true
+	}
+	var order []uuid.UUID
+	for _, t := range a {
+		if in[t.ID] {
+			order = append(order, t.ID)
+		}
+	}
+	for _, t := range b {
+		if len(order) > 0 && order[0] == t.ID {
+			order = order[1:]
+		}
+	}
+	return len(order) == 0
+}
+
 // sync any project dependent state when a project has changed.
 func (ui *UI) sync() {
 	ui.Clear()
 	ui.previous = ui.Project
-	// Allocate one panel per stage.
+	ui.Focus.Reset()
+	if ui.Project == nil {
+		ui.Panels = nil
+		return
+	}
+	// Allocate one panel per stage, re-using panels that already exist for a
+	// stage so that scroll state is retained.
 	ui.Panels = func() (panels []*control.Panel) {
+		existing := make(map[uuid.UUID]*control.Panel, len(ui.Panels))
+		for _, p := range ui.Panels {
+			existing[p.ID] = p
+		}
 		for ii, s := range ui.Project.Stages {
-			panels = append(panels, &control.Panel{
-				Label: s.Name,
-				// First 4 panel colors are hardcoded.
-				// Where to store UI state? Ideally not alongside the stage, since it's
-				// purely a UI concern.
-				// If we have more than four stages, just wrap the colors.
-				// @improve
-				Color: []color.NRGBA{
-					{R: 100, B: 100, G: 200, A: 255},
-					{R: 100, B: 200, G: 100, A: 255},
-					{R: 200, B: 100, G: 100, A: 255},
-					{R: 200, B: 200, G: 100, A: 255},
-				}[ii%4],
-				Thickness: unit.Dp(50),
-			})
+			panel, ok := existing[s.ID]
+			if !ok {
+				panel = &control.Panel{
+					ID:        s.ID,
+					Thickness: unit.Dp(50),
+				}
+			}
+			panel.Label = s.Name
+			panel.Limit = s.Limit
+			// First 4 panel colors are hardcoded.
+			// Where to store UI state? Ideally not alongside the stage, since it's
+			// purely a UI concern.
+			// If we have more than four stages, just wrap the colors.
+			// @improve
+			panel.Color = []color.NRGBA{
+				{R: 100, B: 100, G: 200, A: 255},
+				{R: 100, B: 200, G: 100, A: 255},
+				{R: 200, B: 100, G: 100, A: 255},
+				{R: 200, B: 200, G: 100, A: 255},
+			}[ii%4]
+			panels = append(panels, panel)
 		}
 		return panels
 	}()
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"log"
+	"strconv"
 	"strings"
 	"time"
+	"unsafe"
 
 	"gioui.org/layout"
 	"gioui.org/op"
@@ -15,8 +18,10 @@ import (
 	"gioui.org/x/component"
 	"git.sr.ht/~jackmordaunt/kanban"
 	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/control"
+	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/state"
 	"git.sr.ht/~jackmordaunt/kanban/cmd/kanban/util"
 	"git.sr.ht/~jackmordaunt/kanban/icons"
+	"git.sr.ht/~jackmordaunt/kanban/metrics"
 	"github.com/google/uuid"
 )
 
@@ -35,38 +40,142 @@ const (
 // @Todo use form pattern from avisha.
 type TicketForm struct {
 	kanban.Ticket
-	Stage     string
-	Title     component.TextField
-	Summary   component.TextField
-	Details   component.TextField
+	Stage   uuid.UUID
+	Title   component.TextField
+	Summary component.TextField
+	Details component.TextField
+	// LabelToggles holds a clickable per catalogue label, keyed by label ID.
+	LabelToggles state.Map
+	// PriorityPicker selects the ticket priority by name.
+	PriorityPicker widget.Enum
+	// DueDate holds the due date formatted per dateFormat, empty for none.
+	DueDate component.TextField
+	// AssigneeToggles holds a clickable per person, keyed by person ID.
+	AssigneeToggles state.Map
+	// BlockerToggles holds a bool per candidate blocker, keyed by ticket ID.
+	BlockerToggles state.Map
+	Blockers       layout.List
+	// LinkError explains why the last blocker could not be added.
+	LinkError string
 	SubmitBtn widget.Clickable
 	CancelBtn widget.Clickable
 }
 
 // Edit the provided ticket.
 func (f *TicketForm) Edit(t kanban.Ticket) {
-	f.Ticket = t
+	f.Ticket = t.Clone()
+	f.PriorityPicker.Value = t.Priority.String()
+	f.DueDate.SetText("")
+	if !t.Due.IsZero() {
+		f.DueDate.SetText(t.Due.Format(dateFormat))
+	}
 	f.Title.SetText(t.Title)
 	f.Summary.SetText(t.Summary)
 	f.Details.SetText(t.Details)
 }
 
 // Submit uses form data to create a Ticket.
+// Fields not covered by the form are carried over from the ticket being
+// edited.
 func (f TicketForm) Submit() kanban.Ticket {
 	defer func() {
 		f.Ticket = kanban.Ticket{}
 	}()
-	return kanban.Ticket{
-		ID:      f.ID,
-		Title:   strings.TrimSpace(f.Title.Text()),
-		Summary: f.Summary.Text(),
-		Details: f.Details.Text(),
+	t := f.Ticket
+	t.Title = strings.TrimSpace(f.Title.Text())
+	t.Summary = f.Summary.Text()
+	t.Details = f.Details.Text()
+	if p, err := kanban.ParsePriority(f.PriorityPicker.Value); err == nil {
+		t.Priority = p
+	}
+	if due, err := parseDate(f.DueDate.Text()); err == nil {
+		t.Due = due
+	}
+	return t
+}
+
+// Validate the form data, flagging any fields in error.
+func (f *TicketForm) Validate() bool {
+	f.DueDate.ClearError()
+	if _, err := parseDate(f.DueDate.Text()); err != nil {
+		f.DueDate.SetError(dateFormat)
+		return false
+	}
+	return true
+}
+
+// dateFormat is the layout dates are entered and displayed in.
+const dateFormat = "2006-01-02"
+
+// parseDate parses a local date, where empty text means no date.
+func parseDate(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(dateFormat, text, time.Local)
+}
+
+// ToggleLabel tags the ticket with the label, or untags it if already tagged.
+func (f *TicketForm) ToggleLabel(label uuid.UUID) {
+	if f.Ticket.Tagged(label) {
+		f.Ticket.Untag(label)
+	} else {
+		f.Ticket.Tag(label)
+	}
+}
+
+// ToggleAssignee assigns the ticket to the person, or unassigns them if
+// already assigned.
+func (f *TicketForm) ToggleAssignee(person uuid.UUID) {
+	if f.Ticket.AssignedTo(person) {
+		f.Ticket.Unassign(person)
+	} else {
+		f.Ticket.Assign(person)
+	}
+}
+
+// UpdateBlockers processes the blocker toggles, refusing any blocker that
+// would form a dependency cycle.
+func (f *TicketForm) UpdateBlockers(p *kanban.Project) {
+	for f.BlockerToggles.More() {
+		k, v := f.BlockerToggles.Next()
+		toggle := (*widget.Bool)(v)
+		if !toggle.Changed() {
+			continue
+		}
+		id, err := uuid.Parse(k)
+		if err != nil {
+			continue
+		}
+		f.LinkError = ""
+		if !toggle.Value {
+			f.Ticket.Unblock(id)
+		} else if err := p.Block(&f.Ticket, id); err != nil {
+			f.LinkError = err.Error()
+		}
 	}
 }
 
-func (f *TicketForm) Layout(gtx C, th *material.Theme, stage string) D {
+// Layout the form, offering the labels, people and tickets of the project p.
+func (f *TicketForm) Layout(gtx C, th *material.Theme, stage uuid.UUID, p *kanban.Project) D {
 	f.Stage = stage
 	f.Title.SingleLine = true
+	f.DueDate.SingleLine = true
+	f.LabelToggles.Begin()
+	f.AssigneeToggles.Begin()
+	f.BlockerToggles.Begin()
+	var candidates []kanban.Ticket
+	for _, s := range p.Stages {
+		for _, t := range s.Tickets {
+			if t.ID != f.Ticket.ID {
+				candidates = append(candidates, t)
+			}
+		}
+	}
+	if f.PriorityPicker.Value == "" {
+		f.PriorityPicker.Value = f.Ticket.Priority.String()
+	}
 	return control.Card{
 		Title: func() string {
 			if f.Ticket.ID == uuid.Nil {
@@ -88,6 +197,103 @@ func (f *TicketForm) Layout(gtx C, th *material.Theme, stage string) D {
 				layout.Rigid(func(gtx C) D {
 					return f.Details.Layout(gtx, th, "Details")
 				}),
+				layout.Rigid(func(gtx C) D {
+					return f.DueDate.Layout(gtx, th, "Due date (YYYY-MM-DD)")
+				}),
+				layout.Rigid(func(gtx C) D {
+					return layout.Flex{
+						Axis:      layout.Horizontal,
+						Alignment: layout.Middle,
+					}.Layout(
+						gtx,
+						func() (items []layout.FlexChild) {
+							items = append(items, layout.Rigid(func(gtx C) D {
+								return material.Body2(th, "Priority:").Layout(gtx)
+							}))
+							for _, p := range kanban.Priorities {
+								p := p
+								items = append(items, layout.Rigid(func(gtx C) D {
+									return material.RadioButton(th, &f.PriorityPicker, p.String(), strings.Title(p.String())).Layout(gtx)
+								}))
+							}
+							return items
+						}()...,
+					)
+				}),
+				layout.Rigid(func(gtx C) D {
+					if len(p.Labels) == 0 {
+						return D{}
+					}
+					chips := make([]layout.FlexChild, len(p.Labels))
+					for ii := range p.Labels {
+						label := p.Labels[ii]
+						btn := (*widget.Clickable)(f.LabelToggles.New(label.ID.String(), unsafe.Pointer(&widget.Clickable{})))
+						chips[ii] = layout.Rigid(func(gtx C) D {
+							return layout.Inset{Right: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+								return material.Clickable(gtx, btn, func(gtx C) D {
+									return control.Chip{
+										Label: label.Name,
+										Color: label.Color,
+										Faded: !f.Ticket.Tagged(label.ID),
+									}.Layout(gtx, th)
+								})
+							})
+						})
+					}
+					return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+						return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, chips...)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					if len(p.People) == 0 {
+						return D{}
+					}
+					chips := make([]layout.FlexChild, len(p.People))
+					for ii := range p.People {
+						person := p.People[ii]
+						btn := (*widget.Clickable)(f.AssigneeToggles.New(person.ID.String(), unsafe.Pointer(&widget.Clickable{})))
+						chips[ii] = layout.Rigid(func(gtx C) D {
+							return layout.Inset{Right: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+								return material.Clickable(gtx, btn, func(gtx C) D {
+									return control.Chip{
+										Label: person.Name,
+										Color: personColor(person),
+										Faded: !f.Ticket.AssignedTo(person.ID),
+									}.Layout(gtx, th)
+								})
+							})
+						})
+					}
+					return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+						return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, chips...)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					if len(candidates) == 0 {
+						return D{}
+					}
+					return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+						return material.Body2(th, "Blocked by").Layout(gtx)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Px(unit.Dp(120))
+					f.Blockers.Axis = layout.Vertical
+					return f.Blockers.Layout(gtx, len(candidates), func(gtx C, ii int) D {
+						t := candidates[ii]
+						toggle := (*widget.Bool)(f.BlockerToggles.New(t.ID.String(), unsafe.Pointer(&widget.Bool{})))
+						toggle.Value = f.Ticket.DependsOn(t.ID)
+						return material.CheckBox(th, toggle, t.Title).Layout(gtx)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					if f.LinkError == "" {
+						return D{}
+					}
+					l := material.Caption(th, f.LinkError)
+					l.Color = color.NRGBA{R: 200, A: 255}
+					return l.Layout(gtx)
+				}),
 			)
 		},
 		Actions: []control.Action{
@@ -108,25 +314,338 @@ func (f *TicketForm) Layout(gtx C, th *material.Theme, stage string) D {
 }
 
 // ProjectForm renders a form for manipulating projects.
+//
+// Stage changes are made against a draft copy of the stages so that nothing is
+// applied to the project until the form is submitted.
 type ProjectForm struct {
 	*kanban.Project
-	Name   component.TextField
-	Delete struct {
+	Name component.TextField
+	// Draft holds the stages as they will be once the form is submitted.
+	Draft kanban.Project
+	// StageFields edit the Draft stages, one per stage in the same order.
+	StageFields []*StageField
+	AddStage    widget.Clickable
+	// LabelFields edit the Draft labels, one per label in the same order.
+	LabelFields []*LabelField
+	AddLabel    widget.Clickable
+	// PersonFields edit the Draft people, one per person in the same order.
+	PersonFields []*PersonField
+	AddPerson    widget.Clickable
+	// HardLimits toggles hard enforcement of stage limits.
+	HardLimits widget.Bool
+	// StrictChecklists toggles refusing to finalize tickets with open
+	// checklist items.
+	StrictChecklists widget.Bool
+	// Gate selects the ID of the stage blocked tickets should not pass, empty
+	// for none.
+	Gate widget.Enum
+	// HardBlocking toggles refusing to move blocked tickets past the gate.
+	HardBlocking widget.Bool
+	Delete       struct {
 		Button widget.Clickable
 	}
-	SubmitBtn widget.Clickable
-	CancelBtn widget.Clickable
+	SubmitBtn  widget.Clickable
+	CancelBtn  widget.Clickable
+	List       layout.List
+	LabelList  layout.List
+	PersonList layout.List
+}
+
+// StageField renders the controls for a single stage in the ProjectForm.
+type StageField struct {
+	Name  component.TextField
+	Limit component.TextField
+	// Sorted keeps the stage sorted by priority.
+	Sorted widget.Bool
+	// Stale is the number of days a ticket can sit in the stage before it is
+	// marked stale.
+	Stale  component.TextField
+	Up     widget.Clickable
+	Down   widget.Clickable
+	Delete widget.Clickable
+}
+
+// LabelField renders the controls for a single label in the ProjectForm.
+type LabelField struct {
+	Name component.TextField
+	// Color cycles the label through the palette of label colours.
+	Color  widget.Clickable
+	Delete widget.Clickable
+}
+
+// PersonField renders the controls for a single person in the ProjectForm.
+type PersonField struct {
+	Name   component.TextField
+	Delete widget.Clickable
+}
+
+// labelColors is the palette that label colours are chosen from.
+var labelColors = []color.NRGBA{
+	{R: 211, G: 47, B: 47, A: 255},
+	{R: 25, G: 118, B: 210, A: 255},
+	{R: 56, G: 142, B: 60, A: 255},
+	{R: 245, G: 124, B: 0, A: 255},
+	{R: 123, G: 31, B: 162, A: 255},
+	{R: 0, G: 151, B: 167, A: 255},
+	{R: 251, G: 192, B: 45, A: 255},
+	{R: 97, G: 97, B: 97, A: 255},
+}
+
+// Create prepares the form for a new project with the given stages.
+func (f *ProjectForm) Create(stages ...string) {
+	f.Project = nil
+	f.Draft = kanban.Project{}
+	f.StageFields = nil
+	f.LabelFields = nil
+	f.PersonFields = nil
+	for _, name := range stages {
+		f.Draft.MakeStage(name)
+		f.StageFields = append(f.StageFields, newStageField(name))
+	}
 }
 
 // Edit the provided project.
 func (f *ProjectForm) Edit(p *kanban.Project) {
 	f.Project = p
 	f.Name.SetText(p.Name)
+	f.Draft = p.Clone()
+	f.StageFields = nil
+	for _, s := range f.Draft.Stages {
+		field := newStageField(s.Name)
+		if s.Limit > 0 {
+			field.Limit.SetText(strconv.Itoa(s.Limit))
+		}
+		field.Sorted.Value = s.Sort == kanban.ByPriority
+		if s.StaleAfter > 0 {
+			field.Stale.SetText(strconv.Itoa(int(s.StaleAfter / day)))
+		}
+		f.StageFields = append(f.StageFields, field)
+	}
+	f.LabelFields = nil
+	for _, l := range f.Draft.Labels {
+		f.LabelFields = append(f.LabelFields, newLabelField(l.Name))
+	}
+	f.PersonFields = nil
+	for _, person := range f.Draft.People {
+		f.PersonFields = append(f.PersonFields, newPersonField(person.Name))
+	}
+	f.HardLimits.Value = p.Limits == kanban.Hard
+	f.StrictChecklists.Value = p.StrictChecklists
+	f.Gate.Value = ""
+	if p.Gate != uuid.Nil {
+		f.Gate.Value = p.Gate.String()
+	}
+	f.HardBlocking.Value = p.Blocking == kanban.Hard
+}
+
+func newPersonField(name string) *PersonField {
+	field := &PersonField{}
+	field.Name.SingleLine = true
+	field.Name.SetText(name)
+	return field
+}
+
+func newLabelField(name string) *LabelField {
+	field := &LabelField{}
+	field.Name.SingleLine = true
+	field.Name.SetText(name)
+	return field
+}
+
+func newStageField(name string) *StageField {
+	field := &StageField{}
+	field.Name.SingleLine = true
+	field.Name.SetText(name)
+	field.Limit.SingleLine = true
+	field.Stale.SingleLine = true
+	return field
+}
+
+// Validate the form data, flagging any fields in error.
+func (f *ProjectForm) Validate() bool {
+	ok := true
+	for _, field := range f.StageFields {
+		field.Limit.ClearError()
+		if _, err := parseLimit(field.Limit.Text()); err != nil {
+			field.Limit.SetError("whole number")
+			ok = false
+		}
+		field.Stale.ClearError()
+		if _, err := parseStale(field.Stale.Text()); err != nil {
+			field.Stale.SetError("whole days")
+			ok = false
+		}
+	}
+	return ok
+}
+
+// parseLimit parses a stage limit, where empty text means no limit.
+func parseLimit(text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative limit: %d", n)
+	}
+	return n, nil
+}
+
+// day is the unit that stale thresholds are entered in.
+const day = 24 * time.Hour
+
+// parseStale parses a stale threshold in whole days, where empty text means
+// tickets never go stale.
+func parseStale(text string) (time.Duration, error) {
+	days, err := parseLimit(text)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(days) * day, nil
 }
 
 // Submit writes form data to the entity.
+// In create mode there is no entity, so the caller is expected to persist
+// the Draft.
 func (f *ProjectForm) Submit() {
-	f.Project.Name = f.Name.Text()
+	f.Draft.Name = f.Name.Text()
+	for ii, field := range f.StageFields {
+		if name := strings.TrimSpace(field.Name.Text()); name != "" {
+			f.Draft.Stages[ii].Name = name
+		}
+		if limit, err := parseLimit(field.Limit.Text()); err == nil {
+			f.Draft.Stages[ii].Limit = limit
+		}
+		if stale, err := parseStale(field.Stale.Text()); err == nil {
+			f.Draft.Stages[ii].StaleAfter = stale
+		}
+		f.Draft.Stages[ii].Sort = kanban.Manual
+		if field.Sorted.Value {
+			f.Draft.Stages[ii].Sort = kanban.ByPriority
+		}
+		f.Draft.Stages[ii].Reorder()
+	}
+	for ii, field := range f.LabelFields {
+		if name := strings.TrimSpace(field.Name.Text()); name != "" {
+			f.Draft.Labels[ii].Name = name
+		}
+	}
+	// People are only known by name, so nameless entries are dropped.
+	for ii := len(f.PersonFields) - 1; ii >= 0; ii-- {
+		name := strings.TrimSpace(f.PersonFields[ii].Name.Text())
+		if name == "" {
+			f.RemovePerson(ii)
+			continue
+		}
+		f.Draft.People[ii].Name = name
+	}
+	f.Draft.Limits = kanban.Soft
+	if f.HardLimits.Value {
+		f.Draft.Limits = kanban.Hard
+	}
+	f.Draft.StrictChecklists = f.StrictChecklists.Value
+	f.Draft.Gate = uuid.Nil
+	if gate, err := uuid.Parse(f.Gate.Value); err == nil {
+		f.Draft.Gate = gate
+	}
+	f.Draft.Blocking = kanban.Soft
+	if f.HardBlocking.Value {
+		f.Draft.Blocking = kanban.Hard
+	}
+	if f.Project != nil {
+		f.Project.Name = f.Draft.Name
+		f.Project.Stages = f.Draft.Stages
+		f.Project.Limits = f.Draft.Limits
+		f.Project.Labels = f.Draft.Labels
+		f.Project.People = f.Draft.People
+		f.Project.StrictChecklists = f.Draft.StrictChecklists
+		f.Project.Gate = f.Draft.Gate
+		f.Project.Blocking = f.Draft.Blocking
+	}
+}
+
+// StageNames returns the stage names as currently entered.
+func (f *ProjectForm) StageNames() []string {
+	names := make([]string, len(f.StageFields))
+	for ii, field := range f.StageFields {
+		names[ii] = field.Name.Text()
+	}
+	return names
+}
+
+// NewStage appends a new, empty stage.
+func (f *ProjectForm) NewStage() {
+	name := fmt.Sprintf("Stage %d", len(f.Draft.Stages)+1)
+	f.Draft.MakeStage(name)
+	field := newStageField(name)
+	field.Name.Focus()
+	f.StageFields = append(f.StageFields, field)
+}
+
+// MoveStage shifts the stage at index ii in the given direction.
+func (f *ProjectForm) MoveStage(ii int, dir kanban.Direction) {
+	if f.Draft.MoveStage(f.Draft.Stages[ii].ID, dir) {
+		jj := ii + dir.Next()
+		f.StageFields[ii], f.StageFields[jj] = f.StageFields[jj], f.StageFields[ii]
+	}
+}
+
+// RemoveStage deletes the stage at index ii, moving its tickets into the stage
+// at index into.
+func (f *ProjectForm) RemoveStage(ii, into int) error {
+	var target uuid.UUID
+	if into >= 0 && into < len(f.Draft.Stages) {
+		target = f.Draft.Stages[into].ID
+	}
+	if err := f.Draft.RemoveStage(f.Draft.Stages[ii].ID, target); err != nil {
+		return err
+	}
+	f.StageFields = append(f.StageFields[:ii], f.StageFields[ii+1:]...)
+	return nil
+}
+
+// NewLabel appends a new label, picking the next colour in the palette.
+func (f *ProjectForm) NewLabel(name string) {
+	f.Draft.MakeLabel(name, labelColors[len(f.Draft.Labels)%len(labelColors)])
+	f.LabelFields = append(f.LabelFields, newLabelField(name))
+}
+
+// CycleLabel changes the colour of the label at index ii to the next colour
+// in the palette.
+func (f *ProjectForm) CycleLabel(ii int) {
+	l := &f.Draft.Labels[ii]
+	next := 0
+	for jj, c := range labelColors {
+		if c == l.Color {
+			next = (jj + 1) % len(labelColors)
+			break
+		}
+	}
+	l.Color = labelColors[next]
+}
+
+// RemoveLabel deletes the label at index ii, untagging any tickets that carry
+// it.
+func (f *ProjectForm) RemoveLabel(ii int) {
+	f.Draft.RemoveLabel(f.Draft.Labels[ii].ID)
+	f.LabelFields = append(f.LabelFields[:ii], f.LabelFields[ii+1:]...)
+}
+
+// NewPerson appends a new person to the directory.
+func (f *ProjectForm) NewPerson(name string) {
+	f.Draft.MakePerson(name)
+	f.PersonFields = append(f.PersonFields, newPersonField(name))
+}
+
+// RemovePerson deletes the person at index ii, unassigning them from any
+// tickets.
+func (f *ProjectForm) RemovePerson(ii int) {
+	f.Draft.RemovePerson(f.Draft.People[ii].ID)
+	f.PersonFields = append(f.PersonFields[:ii], f.PersonFields[ii+1:]...)
 }
 
 func (f *ProjectForm) Mode() Mode {
@@ -164,6 +683,35 @@ func (f *ProjectForm) Layout(gtx C, th *material.Theme) D {
 			Float:     control.FloatRight,
 		})
 	}
+	// heading renders a section title with a button to add to the section.
+	heading := func(title string, add *widget.Clickable) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
+			return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+				return layout.Flex{
+					Axis:      layout.Horizontal,
+					Alignment: layout.Middle,
+				}.Layout(
+					gtx,
+					layout.Rigid(func(gtx C) D {
+						return material.Body1(th, title).Layout(gtx)
+					}),
+					layout.Flexed(1, func(gtx C) D {
+						return D{Size: gtx.Constraints.Min}
+					}),
+					layout.Rigid(func(gtx C) D {
+						return util.Button(
+							add,
+							util.WithIcon(icons.ContentAdd),
+							util.WithSize(unit.Dp(16)),
+							util.WithInset(layout.UniformInset(unit.Dp(4))),
+							util.WithBgColor(color.NRGBA{}),
+							util.WithIconColor(th.Fg),
+						).Layout(gtx)
+					}),
+				)
+			})
+		})
+	}
 	return control.Card{
 		Title: title,
 		Body: func(gtx C) D {
@@ -174,12 +722,228 @@ func (f *ProjectForm) Layout(gtx C, th *material.Theme) D {
 				layout.Rigid(func(gtx C) D {
 					return f.Name.Layout(gtx, th, "Project Name")
 				}),
+				heading("Stages", &f.AddStage),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Px(unit.Dp(300))
+					f.List.Axis = layout.Vertical
+					return f.List.Layout(gtx, len(f.StageFields), func(gtx C, ii int) D {
+						return f.StageFields[ii].Layout(gtx, th, fmt.Sprintf("Stage %d", ii+1))
+					})
+				}),
+				heading("Labels", &f.AddLabel),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Px(unit.Dp(200))
+					f.LabelList.Axis = layout.Vertical
+					return f.LabelList.Layout(gtx, len(f.LabelFields), func(gtx C, ii int) D {
+						return f.LabelFields[ii].Layout(gtx, th, f.Draft.Labels[ii].Color)
+					})
+				}),
+				heading("People", &f.AddPerson),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Px(unit.Dp(200))
+					f.PersonList.Axis = layout.Vertical
+					return f.PersonList.Layout(gtx, len(f.PersonFields), func(gtx C, ii int) D {
+						return f.PersonFields[ii].Layout(gtx, th)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					return material.CheckBox(th, &f.HardLimits, "Refuse tickets when a stage is at its limit").Layout(gtx)
+				}),
+				layout.Rigid(func(gtx C) D {
+					return material.CheckBox(th, &f.StrictChecklists, "Refuse to finalize tickets with open checklist items").Layout(gtx)
+				}),
+				layout.Rigid(func(gtx C) D {
+					return layout.Flex{
+						Axis:      layout.Horizontal,
+						Alignment: layout.Middle,
+					}.Layout(
+						gtx,
+						func() (items []layout.FlexChild) {
+							items = append(items,
+								layout.Rigid(func(gtx C) D {
+									return material.Body2(th, "Blocked tickets stop at:").Layout(gtx)
+								}),
+								layout.Rigid(func(gtx C) D {
+									return material.RadioButton(th, &f.Gate, "", "Nowhere").Layout(gtx)
+								}),
+							)
+							names := f.StageNames()
+							for ii, s := range f.Draft.Stages {
+								s, name := s, names[ii]
+								items = append(items, layout.Rigid(func(gtx C) D {
+									return material.RadioButton(th, &f.Gate, s.ID.String(), name).Layout(gtx)
+								}))
+							}
+							return items
+						}()...,
+					)
+				}),
+				layout.Rigid(func(gtx C) D {
+					return material.CheckBox(th, &f.HardBlocking, "Refuse to move blocked tickets past that stage").Layout(gtx)
+				}),
 			)
 		},
 		Actions: actions,
 	}.Layout(gtx, th)
 }
 
+func (s *StageField) Layout(gtx C, th *material.Theme, hint string) D {
+	button := func(c *widget.Clickable, icon *widget.Icon) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
+			return util.Button(
+				c,
+				util.WithIcon(icon),
+				util.WithSize(unit.Dp(16)),
+				util.WithInset(layout.UniformInset(unit.Dp(4))),
+				util.WithBgColor(color.NRGBA{}),
+				util.WithIconColor(th.Fg),
+			).Layout(gtx)
+		})
+	}
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Alignment: layout.Middle,
+	}.Layout(
+		gtx,
+		layout.Flexed(1, func(gtx C) D {
+			return s.Name.Layout(gtx, th, hint)
+		}),
+		layout.Rigid(func(gtx C) D {
+			gtx.Constraints.Max.X = gtx.Px(unit.Dp(80))
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.Inset{Left: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+				return s.Limit.Layout(gtx, th, "Limit")
+			})
+		}),
+		layout.Rigid(func(gtx C) D {
+			gtx.Constraints.Max.X = gtx.Px(unit.Dp(80))
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.Inset{Left: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+				return s.Stale.Layout(gtx, th, "Stale days")
+			})
+		}),
+		layout.Rigid(func(gtx C) D {
+			return material.CheckBox(th, &s.Sorted, "By priority").Layout(gtx)
+		}),
+		button(&s.Up, icons.UpIcon),
+		button(&s.Down, icons.DownIcon),
+		button(&s.Delete, icons.ContentDelete),
+	)
+}
+
+func (l *LabelField) Layout(gtx C, th *material.Theme, c color.NRGBA) D {
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Alignment: layout.Middle,
+	}.Layout(
+		gtx,
+		layout.Flexed(1, func(gtx C) D {
+			return l.Name.Layout(gtx, th, "Label")
+		}),
+		layout.Rigid(func(gtx C) D {
+			return layout.Inset{Left: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+				return material.Clickable(gtx, &l.Color, func(gtx C) D {
+					return util.Rect{
+						Color: c,
+						Size:  layout.FPt(image.Pt(gtx.Px(unit.Dp(24)), gtx.Px(unit.Dp(24)))),
+						Radii: float32(gtx.Px(unit.Dp(12))),
+					}.Layout(gtx)
+				})
+			})
+		}),
+		layout.Rigid(func(gtx C) D {
+			return util.Button(
+				&l.Delete,
+				util.WithIcon(icons.ContentDelete),
+				util.WithSize(unit.Dp(16)),
+				util.WithInset(layout.UniformInset(unit.Dp(4))),
+				util.WithBgColor(color.NRGBA{}),
+				util.WithIconColor(th.Fg),
+			).Layout(gtx)
+		}),
+	)
+}
+
+func (p *PersonField) Layout(gtx C, th *material.Theme) D {
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Alignment: layout.Middle,
+	}.Layout(
+		gtx,
+		layout.Flexed(1, func(gtx C) D {
+			return p.Name.Layout(gtx, th, "Name")
+		}),
+		layout.Rigid(func(gtx C) D {
+			return util.Button(
+				&p.Delete,
+				util.WithIcon(icons.ContentDelete),
+				util.WithSize(unit.Dp(16)),
+				util.WithInset(layout.UniformInset(unit.Dp(4))),
+				util.WithBgColor(color.NRGBA{}),
+				util.WithIconColor(th.Fg),
+			).Layout(gtx)
+		}),
+	)
+}
+
+// personColor picks a stable colour for a person from the label palette.
+func personColor(p kanban.Person) color.NRGBA {
+	return labelColors[int(p.ID[0])%len(labelColors)]
+}
+
+// DeleteStageDialog prompts the user for a stage to move tickets into before
+// deleting a stage that holds tickets.
+type DeleteStageDialog struct {
+	// Stage is the index of the stage being deleted.
+	Stage int
+	// Targets holds one button per stage, indexed the same as the stages.
+	Targets []widget.Clickable
+	Cancel  widget.Clickable
+}
+
+func (d *DeleteStageDialog) Layout(gtx C, th *material.Theme, stages kanban.Stages, names []string) D {
+	if len(d.Targets) != len(stages) {
+		d.Targets = make([]widget.Clickable, len(stages))
+	}
+	return control.Card{
+		Title: "Delete Stage",
+		Subtitle: fmt.Sprintf(
+			"Stage %q holds %d tickets. Move them to:",
+			names[d.Stage],
+			len(stages[d.Stage].Tickets),
+		),
+		Body: func(gtx C) D {
+			return layout.Flex{
+				Axis: layout.Vertical,
+			}.Layout(
+				gtx,
+				func() (buttons []layout.FlexChild) {
+					for ii := range stages {
+						if ii == d.Stage {
+							continue
+						}
+						ii := ii
+						buttons = append(buttons, layout.Rigid(func(gtx C) D {
+							return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx C) D {
+								return material.Button(th, &d.Targets[ii], names[ii]).Layout(gtx)
+							})
+						}))
+					}
+					return buttons
+				}()...,
+			)
+		},
+		Actions: []control.Action{
+			{
+				Clickable: &d.Cancel,
+				Label:     "Cancel",
+				Fg:        th.Fg,
+				Bg:        th.Bg,
+			},
+		},
+	}.Layout(gtx, th)
+}
+
 // DeleteDialog prompts the user with an option to delete a ticket.
 type DeleteDialog struct {
 	kanban.Ticket
@@ -224,9 +988,19 @@ func (d *DeleteDialog) Layout(gtx C, th *material.Theme) D {
 // Ticket renders a ticket control.
 type Ticket struct {
 	kanban.Ticket
-	Stage        string
+	Stage uuid.UUID
+	// Chips are the resolved labels the ticket is tagged with.
+	Chips []kanban.Label
+	// Avatars are the resolved people the ticket is assigned to.
+	Avatars []kanban.Person
+	// Blocked marks the ticket as waiting on other tickets.
+	Blocked bool
+	// Stale marks the ticket as having sat in its stage for too long.
+	Stale        bool
 	NextButton   widget.Clickable
 	PrevButton   widget.Clickable
+	UpButton     widget.Clickable
+	DownButton   widget.Clickable
 	EditButton   widget.Clickable
 	DeleteButton widget.Clickable
 	Content      widget.Clickable
@@ -244,7 +1018,7 @@ type Ticket struct {
 func (t *Ticket) Layout(gtx C, th *material.Theme, focused bool) D {
 	var (
 		barThickness   = unit.Dp(25)
-		sideBarColor   = color.NRGBA{R: 50, G: 50, B: 50, A: 255}
+		sideBarColor   = priorityColor(t.Priority)
 		bottomBarColor = color.NRGBA{R: 220, G: 220, B: 220, A: 255}
 		minContentSize = gtx.Px(unit.Dp(150))
 	)
@@ -256,10 +1030,15 @@ func (t *Ticket) Layout(gtx C, th *material.Theme, focused bool) D {
 			return t.Layout(gtx, th, false)
 		})
 	}
-	return widget.Border{
+	border := widget.Border{
 		Width: unit.Dp(0.5),
 		Color: color.NRGBA{A: 200},
-	}.Layout(gtx, func(gtx C) D {
+	}
+	if c, ok := dueColor(t.Ticket, gtx.Now); ok {
+		border.Width = unit.Dp(2)
+		border.Color = c
+	}
+	return border.Layout(gtx, func(gtx C) D {
 		dims := layout.Inset{
 			Left: unit.Dp(25),
 		}.Layout(gtx, func(gtx C) D {
@@ -269,7 +1048,16 @@ func (t *Ticket) Layout(gtx C, th *material.Theme, focused bool) D {
 				gtx,
 				layout.Rigid(func(gtx C) D {
 					gtx.Constraints.Min.Y = minContentSize
-					return t.content(gtx, th)
+					dims := t.content(gtx, th)
+					if t.Stale {
+						// Fade stale tickets so that they stand out from
+						// active work.
+						util.Rect{
+							Color: component.WithAlpha(th.Bg, 120),
+							Size:  layout.FPt(dims.Size),
+						}.Layout(gtx)
+					}
+					return dims
 				}),
 				layout.Rigid(func(gtx C) D {
 					return t.bottomBar(
@@ -309,7 +1097,24 @@ func (t *Ticket) content(gtx C, th *material.Theme) D {
 		}.Layout(
 			gtx,
 			layout.Rigid(func(gtx C) D {
-				return material.Label(th, unit.Dp(20), t.Title).Layout(gtx)
+				return layout.Flex{
+					Axis:      layout.Horizontal,
+					Alignment: layout.Middle,
+				}.Layout(
+					gtx,
+					layout.Flexed(1, func(gtx C) D {
+						return material.Label(th, unit.Dp(20), t.Title).Layout(gtx)
+					}),
+					layout.Rigid(func(gtx C) D {
+						if !t.Blocked {
+							return D{}
+						}
+						return control.Chip{
+							Label: "Blocked",
+							Color: color.NRGBA{R: 200, A: 255},
+						}.Layout(gtx, th)
+					}),
+				)
 			}),
 			layout.Rigid(func(gtx C) D {
 				return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
@@ -318,6 +1123,36 @@ func (t *Ticket) content(gtx C, th *material.Theme) D {
 					return l.Layout(gtx)
 				})
 			}),
+			layout.Rigid(func(gtx C) D {
+				if t.Due.IsZero() {
+					return D{}
+				}
+				return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+					l := material.Caption(th, "Due "+t.Due.Format(dateFormat))
+					l.Color = component.WithAlpha(l.Color, 200)
+					if c, ok := dueColor(t.Ticket, gtx.Now); ok {
+						l.Color = c
+					}
+					return l.Layout(gtx)
+				})
+			}),
+			layout.Rigid(func(gtx C) D {
+				if len(t.Chips) == 0 {
+					return D{}
+				}
+				chips := make([]layout.FlexChild, len(t.Chips))
+				for ii := range t.Chips {
+					label := t.Chips[ii]
+					chips[ii] = layout.Rigid(func(gtx C) D {
+						return layout.Inset{Right: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+							return control.Chip{Label: label.Name, Color: label.Color}.Layout(gtx, th)
+						})
+					})
+				}
+				return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+					return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, chips...)
+				})
+			}),
 		)
 	})
 	call := macro.Stop()
@@ -356,18 +1191,63 @@ func (t *Ticket) bottomBar(gtx C, th *material.Theme, sz image.Point, c color.NR
 				Alignment: layout.Middle,
 			}.Layout(
 				gtx,
+				layout.Rigid(func(gtx C) D {
+					if !t.Stale {
+						return D{}
+					}
+					return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx C) D {
+						sz := gtx.Px(unit.Dp(8))
+						return util.Rect{
+							Color: staleColor,
+							Size:  layout.FPt(image.Pt(sz, sz)),
+							Radii: float32(sz) / 2,
+						}.Layout(gtx)
+					})
+				}),
 				layout.Rigid(func(gtx C) D {
 					return layout.Inset{
 						Left: unit.Px(10),
 					}.Layout(gtx, func(gtx C) D {
-						return material.Label(th, unit.Dp(10), func() string {
-							d := time.Since(t.Created)
-							d = d.Round(time.Minute)
-							h := d / time.Hour
-							d -= h * time.Hour
-							m := d / time.Minute
-							return fmt.Sprintf("%02d:%02d", h, m)
-						}()).Layout(gtx)
+						l := material.Label(th, unit.Dp(10), fmt.Sprintf(
+							"%s old · %s here",
+							formatAge(gtx.Now.Sub(t.Created)),
+							formatAge(gtx.Now.Sub(t.Entered())),
+						))
+						if t.Stale {
+							l.Color = staleColor
+						}
+						return l.Layout(gtx)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					done, total := t.Progress()
+					if total == 0 {
+						return D{}
+					}
+					return layout.Inset{Left: unit.Dp(6)}.Layout(gtx, func(gtx C) D {
+						l := material.Label(th, unit.Dp(10), fmt.Sprintf("%d/%d", done, total))
+						if done == total {
+							l.Color = color.NRGBA{G: 130, A: 255}
+						}
+						return l.Layout(gtx)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					avatars := make([]layout.FlexChild, len(t.Avatars))
+					for ii := range t.Avatars {
+						person := t.Avatars[ii]
+						avatars[ii] = layout.Rigid(func(gtx C) D {
+							return layout.Inset{Left: unit.Dp(4)}.Layout(gtx, func(gtx C) D {
+								return control.Avatar{
+									Initials: person.Initials(),
+									Color:    personColor(person),
+									Size:     unit.Dp(18),
+								}.Layout(gtx, th)
+							})
+						})
+					}
+					return layout.Inset{Left: unit.Dp(6)}.Layout(gtx, func(gtx C) D {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, avatars...)
 					})
 				}),
 				layout.Flexed(1, func(gtx C) D {
@@ -375,8 +1255,8 @@ func (t *Ticket) bottomBar(gtx C, th *material.Theme, sz image.Point, c color.NR
 				}),
 				layout.Rigid(func(gtx C) D {
 					return util.Button(
-						&t.PrevButton,
-						util.WithIcon(icons.BackIcon),
+						&t.UpButton,
+						util.WithIcon(icons.UpIcon),
 						util.WithSize(unit.Dp(12)),
 						util.WithInset(layout.UniformInset(unit.Dp(6))),
 						util.WithIconColor(color.NRGBA{R: 0, G: 0, B: 0, A: 255}),
@@ -385,21 +1265,103 @@ func (t *Ticket) bottomBar(gtx C, th *material.Theme, sz image.Point, c color.NR
 				}),
 				layout.Rigid(func(gtx C) D {
 					return util.Button(
-						&t.NextButton,
-						util.WithIcon(icons.ForwardIcon),
+						&t.DownButton,
+						util.WithIcon(icons.DownIcon),
 						util.WithSize(unit.Dp(12)),
 						util.WithInset(layout.UniformInset(unit.Dp(6))),
 						util.WithIconColor(color.NRGBA{R: 0, G: 0, B: 0, A: 255}),
 						util.WithBgColor(c),
 					).Layout(gtx)
 				}),
-			)
-		}),
-	)
-}
-
-func (t *Ticket) sideBar(gtx C, sz image.Point, c color.NRGBA) D {
-	return layout.Stack{}.Layout(
+				layout.Rigid(func(gtx C) D {
+					return util.Button(
+						&t.PrevButton,
+						util.WithIcon(icons.BackIcon),
+						util.WithSize(unit.Dp(12)),
+						util.WithInset(layout.UniformInset(unit.Dp(6))),
+						util.WithIconColor(color.NRGBA{R: 0, G: 0, B: 0, A: 255}),
+						util.WithBgColor(c),
+					).Layout(gtx)
+				}),
+				layout.Rigid(func(gtx C) D {
+					return util.Button(
+						&t.NextButton,
+						util.WithIcon(icons.ForwardIcon),
+						util.WithSize(unit.Dp(12)),
+						util.WithInset(layout.UniformInset(unit.Dp(6))),
+						util.WithIconColor(color.NRGBA{R: 0, G: 0, B: 0, A: 255}),
+						util.WithBgColor(c),
+					).Layout(gtx)
+				}),
+			)
+		}),
+	)
+}
+
+// staleColor marks tickets that have sat in their stage for too long.
+var staleColor = color.NRGBA{R: 191, G: 54, B: 12, A: 255}
+
+// formatAge formats a duration coarsely, in the largest whole unit, such as
+// "3d", "2w" or "4mo".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < day:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 14*day:
+		return fmt.Sprintf("%dd", int(d/day))
+	case d < 60*day:
+		return fmt.Sprintf("%dw", int(d/(7*day)))
+	case d < 365*day:
+		return fmt.Sprintf("%dmo", int(d/(30*day)))
+	}
+	return fmt.Sprintf("%dy", int(d/(365*day)))
+}
+
+// dueSoon is how close to its due date a ticket must be to be highlighted.
+const dueSoon = 3 * 24 * time.Hour
+
+// soon collects the tickets in the project that are overdue or fall due soon
+// as of now.
+func soon(p *kanban.Project, now time.Time) map[uuid.UUID]bool {
+	soon := make(map[uuid.UUID]bool)
+	for _, t := range p.TicketsByDue() {
+		if !t.Overdue(now) && !t.DueWithin(now, dueSoon) {
+			break
+		}
+		soon[t.ID] = true
+	}
+	return soon
+}
+
+// dueColor returns the colour that highlights an overdue or soon due ticket.
+// False means the ticket needs no highlight.
+func dueColor(t kanban.Ticket, now time.Time) (color.NRGBA, bool) {
+	switch {
+	case t.Overdue(now):
+		return color.NRGBA{R: 200, A: 255}, true
+	case t.DueWithin(now, dueSoon):
+		return color.NRGBA{R: 230, G: 140, A: 255}, true
+	}
+	return color.NRGBA{}, false
+}
+
+// priorityColor returns the colour that signals the given priority.
+func priorityColor(p kanban.Priority) color.NRGBA {
+	switch p {
+	case kanban.Critical:
+		return color.NRGBA{R: 183, G: 28, B: 28, A: 255}
+	case kanban.High:
+		return color.NRGBA{R: 230, G: 81, A: 255}
+	case kanban.Low:
+		return color.NRGBA{R: 120, G: 144, B: 156, A: 255}
+	}
+	return color.NRGBA{R: 50, G: 50, B: 50, A: 255}
+}
+
+func (t *Ticket) sideBar(gtx C, sz image.Point, c color.NRGBA) D {
+	return layout.Stack{}.Layout(
 		gtx,
 		layout.Stacked(func(gtx C) D {
 			util.Rect{
@@ -442,19 +1404,292 @@ func (t *Ticket) sideBar(gtx C, sz image.Point, c color.NRGBA) D {
 	)
 }
 
-// TicketDetails renders the read-only long form details of a ticket.
+// TicketDetails renders the long form details of a ticket.
+// The details are read-only, but the checklist and comments can be edited in
+// place.
 type TicketDetails struct {
 	kanban.Ticket
+	// Items holds the controls for each checklist item, in the same order.
+	Items   []*ItemField
+	NewItem component.TextField
+	AddItem widget.Clickable
+	List    layout.List
+	// Comments holds a CommentField per comment, keyed by comment ID.
+	Comments   state.Map
+	NewComment component.TextField
+	Post       widget.Clickable
+	Thread     layout.List
+	// Editing is the ID of the comment being edited in NewComment, nil when
+	// writing a new comment.
+	Editing  uuid.UUID
+	Timeline layout.List
+	Edit     widget.Clickable
+	Cancel   widget.Clickable
+}
+
+// links lays out the titles of the tickets linked to a ticket, if any.
+func links(gtx C, th *material.Theme, label string, tickets []kanban.Ticket) D {
+	if len(tickets) == 0 {
+		return D{}
+	}
+	titles := make([]string, len(tickets))
+	for ii, t := range tickets {
+		titles[ii] = t.Title
+	}
+	return layout.Inset{Top: unit.Dp(4)}.Layout(gtx, func(gtx C) D {
+		return material.Caption(th, label+": "+strings.Join(titles, ", ")).Layout(gtx)
+	})
+}
+
+// CommentField renders the controls for a single comment.
+type CommentField struct {
 	Edit   widget.Clickable
-	Cancel widget.Clickable
+	Delete widget.Clickable
+}
+
+// ItemField renders the controls for a single checklist item.
+type ItemField struct {
+	Done   widget.Bool
+	Up     widget.Clickable
+	Down   widget.Clickable
+	Delete widget.Clickable
+}
+
+// Changed processes checklist and comment events on behalf of user, returning
+// the ticket as it should be after the edit.
+// False means the ticket is unchanged.
+func (t *TicketDetails) Changed(user string) (kanban.Ticket, bool) {
+	after := t.Ticket
+	if after, ok := t.commented(user); ok {
+		return after, true
+	}
+	submitted := t.AddItem.Clicked()
+	for _, e := range t.NewItem.Events() {
+		if _, ok := e.(widget.SubmitEvent); ok {
+			submitted = true
+		}
+	}
+	if text := strings.TrimSpace(t.NewItem.Text()); submitted && text != "" {
+		t.NewItem.SetText("")
+		after.AddItem(text)
+		return after, true
+	}
+	for ii, item := range t.Items {
+		if ii >= len(t.Checklist) {
+			break
+		}
+		if item.Done.Changed() {
+			after.CheckItem(ii, item.Done.Value)
+			return after, true
+		}
+		if item.Up.Clicked() {
+			return after, after.MoveItem(ii, kanban.Backward)
+		}
+		if item.Down.Clicked() {
+			return after, after.MoveItem(ii, kanban.Forward)
+		}
+		if item.Delete.Clicked() {
+			after.RemoveItem(ii)
+			return after, true
+		}
+	}
+	return after, false
+}
+
+// commented processes comment events on behalf of user.
+func (t *TicketDetails) commented(user string) (kanban.Ticket, bool) {
+	after := t.Ticket
+	submitted := t.Post.Clicked()
+	for _, e := range t.NewComment.Events() {
+		if _, ok := e.(widget.SubmitEvent); ok {
+			submitted = true
+		}
+	}
+	if text := strings.TrimSpace(t.NewComment.Text()); submitted && text != "" {
+		var err error
+		if t.Editing != uuid.Nil {
+			err = after.EditComment(t.Editing, user, text)
+		} else {
+			after.AddComment(user, text)
+		}
+		t.Editing = uuid.Nil
+		t.NewComment.SetText("")
+		if err != nil {
+			log.Printf("editing comment: %v", err)
+			return after, false
+		}
+		return after, true
+	}
+	for t.Comments.More() {
+		k, v := t.Comments.Next()
+		field := (*CommentField)(v)
+		id, err := uuid.Parse(k)
+		if err != nil {
+			continue
+		}
+		if field.Edit.Clicked() {
+			for _, c := range t.Ticket.Comments {
+				if c.ID == id {
+					t.Editing = id
+					t.NewComment.SetText(c.Text)
+					t.NewComment.Focus()
+				}
+			}
+		}
+		if field.Delete.Clicked() {
+			if err := after.DeleteComment(id, user); err != nil {
+				log.Printf("deleting comment: %v", err)
+				continue
+			}
+			if t.Editing == id {
+				t.Editing = uuid.Nil
+				t.NewComment.SetText("")
+			}
+			return after, true
+		}
+	}
+	return after, false
 }
 
-func (t *TicketDetails) Layout(gtx C, th *material.Theme) D {
+// Layout the details, offering edits of the comments written by user.
+// Stages referred to by the ticket's history are named using the project p.
+func (t *TicketDetails) Layout(gtx C, th *material.Theme, p *kanban.Project, user string) D {
+	t.Comments.Begin()
+	t.NewComment.SingleLine = true
+	t.NewComment.Submit = true
+	for len(t.Items) < len(t.Checklist) {
+		t.Items = append(t.Items, &ItemField{})
+	}
+	t.Items = t.Items[:len(t.Checklist)]
+	for ii, item := range t.Checklist {
+		t.Items[ii].Done.Value = item.Done
+	}
+	t.NewItem.SingleLine = true
+	t.NewItem.Submit = true
 	return control.Card{
 		Title:    t.Title,
 		Subtitle: t.Summary,
 		Body: func(gtx C) D {
-			return material.Body1(th, t.Details).Layout(gtx)
+			return layout.Flex{
+				Axis: layout.Vertical,
+			}.Layout(
+				gtx,
+				layout.Rigid(func(gtx C) D {
+					return material.Body1(th, t.Details).Layout(gtx)
+				}),
+				layout.Rigid(func(gtx C) D {
+					return links(gtx, th, "Blocked by", p.Blockers(t.Ticket))
+				}),
+				layout.Rigid(func(gtx C) D {
+					return links(gtx, th, "Blocks", p.Blocks(t.Ticket))
+				}),
+				layout.Rigid(func(gtx C) D {
+					done, total := t.Progress()
+					label := "Checklist"
+					if total > 0 {
+						label = fmt.Sprintf("Checklist %d/%d", done, total)
+					}
+					return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+						return material.Body2(th, label).Layout(gtx)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Px(unit.Dp(250))
+					t.List.Axis = layout.Vertical
+					return t.List.Layout(gtx, len(t.Checklist), func(gtx C, ii int) D {
+						return t.Items[ii].Layout(gtx, th, t.Checklist[ii].Text)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					return layout.Flex{
+						Axis:      layout.Horizontal,
+						Alignment: layout.Middle,
+					}.Layout(
+						gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return t.NewItem.Layout(gtx, th, "New item")
+						}),
+						layout.Rigid(func(gtx C) D {
+							return util.Button(
+								&t.AddItem,
+								util.WithIcon(icons.ContentAdd),
+								util.WithSize(unit.Dp(16)),
+								util.WithInset(layout.UniformInset(unit.Dp(4))),
+								util.WithBgColor(color.NRGBA{}),
+								util.WithIconColor(th.Fg),
+							).Layout(gtx)
+						}),
+					)
+				}),
+				layout.Rigid(func(gtx C) D {
+					return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+						return material.Body2(th, fmt.Sprintf("Comments (%d)", len(t.Ticket.Comments))).Layout(gtx)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Px(unit.Dp(250))
+					t.Thread.Axis = layout.Vertical
+					return t.Thread.Layout(gtx, len(t.Ticket.Comments), func(gtx C, ii int) D {
+						c := t.Ticket.Comments[ii]
+						field := (*CommentField)(t.Comments.New(c.ID.String(), unsafe.Pointer(&CommentField{})))
+						return field.Layout(gtx, th, c, c.Author == user)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					hint := "Add a comment"
+					if t.Editing != uuid.Nil {
+						hint = "Edit comment"
+					}
+					return layout.Flex{
+						Axis:      layout.Horizontal,
+						Alignment: layout.Middle,
+					}.Layout(
+						gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return t.NewComment.Layout(gtx, th, hint)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return util.Button(
+								&t.Post,
+								util.WithIcon(icons.Send),
+								util.WithSize(unit.Dp(16)),
+								util.WithInset(layout.UniformInset(unit.Dp(4))),
+								util.WithBgColor(color.NRGBA{}),
+								util.WithIconColor(th.Fg),
+							).Layout(gtx)
+						}),
+					)
+				}),
+				layout.Rigid(func(gtx C) D {
+					return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+						return material.Body2(th, "History").Layout(gtx)
+					})
+				}),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Px(unit.Dp(200))
+					t.Timeline.Axis = layout.Vertical
+					// Newest first, since recent activity is the most relevant.
+					return t.Timeline.Layout(gtx, len(t.History), func(gtx C, ii int) D {
+						e := t.History[len(t.History)-1-ii]
+						return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx C) D {
+							return layout.Flex{
+								Axis: layout.Horizontal,
+							}.Layout(
+								gtx,
+								layout.Rigid(func(gtx C) D {
+									gtx.Constraints.Min.X = gtx.Px(unit.Dp(110))
+									l := material.Caption(th, e.At.Format("2006-01-02 15:04"))
+									l.Color = component.WithAlpha(l.Color, 180)
+									return l.Layout(gtx)
+								}),
+								layout.Flexed(1, func(gtx C) D {
+									return material.Caption(th, describe(p, e)).Layout(gtx)
+								}),
+							)
+						})
+					})
+				}),
+			)
 		},
 		Actions: []control.Action{
 			{
@@ -473,6 +1708,108 @@ func (t *TicketDetails) Layout(gtx C, th *material.Theme) D {
 	}.Layout(gtx, th)
 }
 
+// describe summarises a ticket event, naming stages using the project p.
+func describe(p *kanban.Project, e kanban.Event) string {
+	stage := func(id uuid.UUID) string {
+		if ii, ok := p.Stages.Index(id); ok {
+			return fmt.Sprintf("%q", p.Stages[ii].Name)
+		}
+		return "a deleted stage"
+	}
+	switch e.Kind {
+	case kanban.Created:
+		return "Created in " + stage(e.To)
+	case kanban.Moved:
+		return fmt.Sprintf("Moved from %s to %s", stage(e.From), stage(e.To))
+	case kanban.Finalized:
+		return "Finalized from " + stage(e.From)
+	case kanban.Restored:
+		return "Restored into " + stage(e.To)
+	case kanban.Edited:
+		lines := make([]string, len(e.Changes))
+		for ii, c := range e.Changes {
+			lines[ii] = fmt.Sprintf("Changed %s from %q to %q", c.Field, c.Before, c.After)
+		}
+		return strings.Join(lines, "\n")
+	}
+	return e.Kind.String()
+}
+
+// Layout the comment, with controls to edit and delete it if own is set.
+func (f *CommentField) Layout(gtx C, th *material.Theme, c kanban.Comment, own bool) D {
+	button := func(btn *widget.Clickable, icon *widget.Icon) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
+			if !own {
+				return D{}
+			}
+			return util.Button(
+				btn,
+				util.WithIcon(icon),
+				util.WithSize(unit.Dp(14)),
+				util.WithInset(layout.UniformInset(unit.Dp(4))),
+				util.WithBgColor(color.NRGBA{}),
+				util.WithIconColor(th.Fg),
+			).Layout(gtx)
+		})
+	}
+	return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx C) D {
+		return layout.Flex{
+			Axis: layout.Vertical,
+		}.Layout(
+			gtx,
+			layout.Rigid(func(gtx C) D {
+				return layout.Flex{
+					Axis:      layout.Horizontal,
+					Alignment: layout.Middle,
+				}.Layout(
+					gtx,
+					layout.Flexed(1, func(gtx C) D {
+						meta := fmt.Sprintf("%s · %s", c.Author, c.Created.Format("2006-01-02 15:04"))
+						if !c.Edited.IsZero() {
+							meta += " (edited)"
+						}
+						l := material.Caption(th, meta)
+						l.Color = component.WithAlpha(l.Color, 180)
+						return l.Layout(gtx)
+					}),
+					button(&f.Edit, icons.ContentEdit),
+					button(&f.Delete, icons.ContentDelete),
+				)
+			}),
+			layout.Rigid(func(gtx C) D {
+				return material.Body2(th, c.Text).Layout(gtx)
+			}),
+		)
+	})
+}
+
+func (item *ItemField) Layout(gtx C, th *material.Theme, text string) D {
+	button := func(c *widget.Clickable, icon *widget.Icon) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
+			return util.Button(
+				c,
+				util.WithIcon(icon),
+				util.WithSize(unit.Dp(16)),
+				util.WithInset(layout.UniformInset(unit.Dp(4))),
+				util.WithBgColor(color.NRGBA{}),
+				util.WithIconColor(th.Fg),
+			).Layout(gtx)
+		})
+	}
+	return layout.Flex{
+		Axis:      layout.Horizontal,
+		Alignment: layout.Middle,
+	}.Layout(
+		gtx,
+		layout.Flexed(1, func(gtx C) D {
+			return material.CheckBox(th, &item.Done, text).Layout(gtx)
+		}),
+		button(&item.Up, icons.UpIcon),
+		button(&item.Down, icons.DownIcon),
+		button(&item.Delete, icons.ContentDelete),
+	)
+}
+
 // ArchiveProjectConfirmation displays a dialog prompting the user to confirm
 // the name of the project to confirm the archival.
 // This is a safety step to avoid accidentally archiving a project.
@@ -512,3 +1849,549 @@ func (dpc *ArchiveProjectConfirmation) Layout(gtx C, th *material.Theme) D {
 		},
 	}.Layout(gtx, th)
 }
+
+// FinalizedArchive lists the finalized tickets of a project, most recent first,
+// and allows them to be restored into a stage.
+type FinalizedArchive struct {
+	Search component.TextField
+	// Stage holds the ID of the stage to restore tickets into.
+	Stage   widget.Enum
+	Restore state.Map
+	Close   widget.Clickable
+	List    layout.List
+}
+
+// Restored reports which ticket was chosen for restoration, if any.
+// Reports the first click encountered.
+func (a *FinalizedArchive) Restored() (uuid.UUID, bool) {
+	return clicked(&a.Restore)
+}
+
+// Into returns the ID of the stage to restore tickets into.
+func (a *FinalizedArchive) Into() uuid.UUID {
+	id, _ := uuid.Parse(a.Stage.Value)
+	return id
+}
+
+func (a *FinalizedArchive) Layout(gtx C, th *material.Theme, p *kanban.Project) D {
+	a.Search.SingleLine = true
+	a.Restore.Begin()
+	if _, ok := p.Stages.Index(a.Into()); !ok && len(p.Stages) > 0 {
+		a.Stage.Value = p.Stages[len(p.Stages)-1].ID.String()
+	}
+	var (
+		query   = strings.ToLower(strings.TrimSpace(a.Search.Text()))
+		tickets []kanban.Ticket
+	)
+	for ii := len(p.Finalized) - 1; ii >= 0; ii-- {
+		if t := p.Finalized[ii]; query == "" || strings.Contains(
+			strings.ToLower(strings.Join([]string{t.Title, t.Summary, t.Details}, " ")),
+			query,
+		) {
+			tickets = append(tickets, t)
+		}
+	}
+	return control.Card{
+		Title:    "Done",
+		Subtitle: fmt.Sprintf("%d finalized tickets", len(p.Finalized)),
+		Body: func(gtx C) D {
+			return layout.Flex{
+				Axis: layout.Vertical,
+			}.Layout(
+				gtx,
+				layout.Rigid(func(gtx C) D {
+					return a.Search.Layout(gtx, th, "Search")
+				}),
+				layout.Rigid(func(gtx C) D {
+					return layout.Flex{
+						Axis:      layout.Horizontal,
+						Alignment: layout.Middle,
+					}.Layout(
+						gtx,
+						func() (items []layout.FlexChild) {
+							items = append(items, layout.Rigid(func(gtx C) D {
+								return material.Body2(th, "Restore into:").Layout(gtx)
+							}))
+							for _, s := range p.Stages {
+								s := s
+								items = append(items, layout.Rigid(func(gtx C) D {
+									return material.RadioButton(th, &a.Stage, s.ID.String(), s.Name).Layout(gtx)
+								}))
+							}
+							return items
+						}()...,
+					)
+				}),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Px(unit.Dp(300))
+					a.List.Axis = layout.Vertical
+					return a.List.Layout(gtx, len(tickets), func(gtx C, ii int) D {
+						t := tickets[ii]
+						btn := (*widget.Clickable)(a.Restore.New(t.ID.String(), unsafe.Pointer(&widget.Clickable{})))
+						return layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+							return layout.Flex{
+								Axis:      layout.Horizontal,
+								Alignment: layout.Middle,
+							}.Layout(
+								gtx,
+								layout.Flexed(1, func(gtx C) D {
+									return layout.Flex{
+										Axis: layout.Vertical,
+									}.Layout(
+										gtx,
+										layout.Rigid(func(gtx C) D {
+											return material.Body1(th, t.Title).Layout(gtx)
+										}),
+										layout.Rigid(func(gtx C) D {
+											finalized := "Finalized at an unknown time"
+											if !t.Finalized.IsZero() {
+												finalized = fmt.Sprintf("Finalized %s", t.Finalized.Format("2006-01-02 15:04"))
+											}
+											l := material.Caption(th, finalized)
+											l.Color = component.WithAlpha(l.Color, 200)
+											return l.Layout(gtx)
+										}),
+									)
+								}),
+								layout.Rigid(func(gtx C) D {
+									return material.Button(th, btn, "Restore").Layout(gtx)
+								}),
+							)
+						})
+					})
+				}),
+			)
+		},
+		Actions: []control.Action{
+			{
+				Clickable: &a.Close,
+				Label:     "Close",
+				Fg:        th.Fg,
+				Bg:        th.Bg,
+			},
+		},
+	}.Layout(gtx, th)
+}
+
+// ArchivedProjects lists archived projects, allowing them to be restored or
+// permanently deleted.
+type ArchivedProjects struct {
+	Projects []kanban.Project
+	Restore  state.Map
+	Delete   state.Map
+	Close    widget.Clickable
+	List     layout.List
+}
+
+// Restored reports which project was chosen for restoration, if any.
+func (a *ArchivedProjects) Restored() (uuid.UUID, bool) {
+	return clicked(&a.Restore)
+}
+
+// Deleted reports which project was chosen for deletion, if any.
+func (a *ArchivedProjects) Deleted() (uuid.UUID, bool) {
+	return clicked(&a.Delete)
+}
+
+// clicked reports the ID keying the first clicked button in the map, if any.
+// Reports the first click encountered.
+func clicked(m *state.Map) (uuid.UUID, bool) {
+	for m.More() {
+		k, v := m.Next()
+		if (*widget.Clickable)(v).Clicked() {
+			if id, err := uuid.Parse(k); err == nil {
+				return id, true
+			}
+		}
+	}
+	return uuid.Nil, false
+}
+
+func (a *ArchivedProjects) Layout(gtx C, th *material.Theme) D {
+	a.Restore.Begin()
+	a.Delete.Begin()
+	return control.Card{
+		Title: "Archived Projects",
+		Subtitle: func() string {
+			if len(a.Projects) == 0 {
+				return "No projects have been archived."
+			}
+			return ""
+		}(),
+		Body: func(gtx C) D {
+			gtx.Constraints.Max.Y = gtx.Px(unit.Dp(300))
+			a.List.Axis = layout.Vertical
+			return a.List.Layout(gtx, len(a.Projects), func(gtx C, ii int) D {
+				p := a.Projects[ii]
+				var (
+					restore = (*widget.Clickable)(a.Restore.New(p.ID.String(), unsafe.Pointer(&widget.Clickable{})))
+					remove  = (*widget.Clickable)(a.Delete.New(p.ID.String(), unsafe.Pointer(&widget.Clickable{})))
+				)
+				return layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+					return layout.Flex{
+						Axis:      layout.Horizontal,
+						Alignment: layout.Middle,
+					}.Layout(
+						gtx,
+						layout.Flexed(1, func(gtx C) D {
+							return material.Body1(th, p.Name).Layout(gtx)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return material.Button(th, restore, "Restore").Layout(gtx)
+						}),
+						layout.Rigid(func(gtx C) D {
+							return layout.Inset{Left: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+								btn := material.Button(th, remove, "Delete")
+								btn.Background = color.NRGBA{R: 200, A: 255}
+								return btn.Layout(gtx)
+							})
+						}),
+					)
+				})
+			})
+		},
+		Actions: []control.Action{
+			{
+				Clickable: &a.Close,
+				Label:     "Close",
+				Fg:        th.Fg,
+				Bg:        th.Bg,
+			},
+		},
+	}.Layout(gtx, th)
+}
+
+// DeleteProjectConfirmation displays a dialog prompting the user to confirm
+// the name of the project to confirm permanent deletion.
+// This is a safety step to avoid accidentally destroying a project.
+type DeleteProjectConfirmation struct {
+	Project      kanban.Project
+	Confirmation component.TextField
+	SubmitBtn    widget.Clickable
+	CancelBtn    widget.Clickable
+}
+
+func (dpc *DeleteProjectConfirmation) Layout(gtx C, th *material.Theme) D {
+	return control.Card{
+		Title:    "Delete Project",
+		Subtitle: fmt.Sprintf("Permanently delete %q? This cannot be undone.", dpc.Project.Name),
+		Body: func(gtx C) D {
+			return layout.Flex{
+				Axis:      layout.Vertical,
+				Alignment: layout.Middle,
+			}.Layout(
+				gtx,
+				layout.Rigid(func(gtx C) D {
+					return dpc.Confirmation.Layout(gtx, th, "Confirm project name")
+				}),
+			)
+		},
+		Actions: []control.Action{
+			{
+				Clickable: &dpc.SubmitBtn,
+				Label:     "Delete",
+				Fg:        th.ContrastFg,
+				Bg:        color.NRGBA{R: 200, A: 255},
+			},
+			{
+				Clickable: &dpc.CancelBtn,
+				Label:     "Cancel",
+				Fg:        th.Fg,
+				Bg:        th.Bg,
+			},
+		},
+	}.Layout(gtx, th)
+}
+
+// Stats renders the flow metrics of a project.
+type Stats struct {
+	// Started selects the ID of the stage that work is considered started
+	// in.
+	Started widget.Enum
+	// Window selects how many days back to measure.
+	Window widget.Enum
+	List   layout.List
+	Close  widget.Clickable
+}
+
+// windows lists the choices of window, in days.
+var windows = []int{7, 30, 90, 365}
+
+func (s *Stats) Layout(gtx C, th *material.Theme, p *kanban.Project) D {
+	started, _ := uuid.Parse(s.Started.Value)
+	if _, ok := p.Stages.Index(started); !ok && len(p.Stages) > 0 {
+		// Default to the second stage, as the first typically holds work
+		// that has not been started.
+		started = p.Stages[0].ID
+		if len(p.Stages) > 1 {
+			started = p.Stages[1].ID
+		}
+		s.Started.Value = started.String()
+	}
+	if s.Window.Value == "" {
+		s.Window.Value = "30"
+	}
+	days, _ := strconv.Atoi(s.Window.Value)
+	report := metrics.Measure(p, started, metrics.LastDays(gtx.Now, days))
+	radios := func(label string, e *widget.Enum, keys, names []string) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
+			items := []layout.FlexChild{
+				layout.Rigid(func(gtx C) D {
+					return material.Body2(th, label).Layout(gtx)
+				}),
+			}
+			for ii := range keys {
+				key, name := keys[ii], names[ii]
+				items = append(items, layout.Rigid(func(gtx C) D {
+					return material.RadioButton(th, e, key, name).Layout(gtx)
+				}))
+			}
+			return layout.Flex{
+				Axis:      layout.Horizontal,
+				Alignment: layout.Middle,
+			}.Layout(gtx, items...)
+		})
+	}
+	var stageKeys, stageNames, windowKeys, windowNames []string
+	for _, stage := range p.Stages {
+		stageKeys = append(stageKeys, stage.ID.String())
+		stageNames = append(stageNames, stage.Name)
+	}
+	for _, days := range windows {
+		windowKeys = append(windowKeys, strconv.Itoa(days))
+		windowNames = append(windowNames, fmt.Sprintf("%dd", days))
+	}
+	summary := func(label string, pc metrics.Percentiles) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
+			text := fmt.Sprintf("%s: no tickets", label)
+			if pc.N > 0 {
+				text = fmt.Sprintf(
+					"%s over %d tickets: p50 %s, p85 %s, p95 %s",
+					label, pc.N, formatDuration(pc.P50), formatDuration(pc.P85), formatDuration(pc.P95),
+				)
+			}
+			return layout.Inset{Top: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+				return material.Body1(th, text).Layout(gtx)
+			})
+		})
+	}
+	return control.Card{
+		Title:    "Flow Metrics",
+		Subtitle: fmt.Sprintf("%d tickets finalized in the last %d days", len(report.Tickets), days),
+		Body: func(gtx C) D {
+			return layout.Flex{
+				Axis: layout.Vertical,
+			}.Layout(
+				gtx,
+				radios("Work starts in:", &s.Started, stageKeys, stageNames),
+				radios("Window:", &s.Window, windowKeys, windowNames),
+				summary("Lead time", report.Lead),
+				summary("Cycle time", report.Cycle),
+				layout.Rigid(func(gtx C) D {
+					gtx.Constraints.Max.Y = gtx.Px(unit.Dp(300))
+					s.List.Axis = layout.Vertical
+					return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx C) D {
+						return s.List.Layout(gtx, len(report.Tickets), func(gtx C, ii int) D {
+							t := report.Tickets[len(report.Tickets)-1-ii]
+							cycle := "not started"
+							if t.Started {
+								cycle = formatDuration(t.Cycle)
+							}
+							return layout.Flex{
+								Axis: layout.Horizontal,
+							}.Layout(
+								gtx,
+								layout.Flexed(1, func(gtx C) D {
+									return material.Body2(th, t.Ticket.Title).Layout(gtx)
+								}),
+								layout.Rigid(func(gtx C) D {
+									l := material.Caption(th, fmt.Sprintf("lead %s, cycle %s", formatDuration(t.Lead), cycle))
+									l.Color = component.WithAlpha(l.Color, 200)
+									return l.Layout(gtx)
+								}),
+							)
+						})
+					})
+				}),
+			)
+		},
+		Actions: []control.Action{
+			{
+				Clickable: &s.Close,
+				Label:     "Close",
+				Fg:        th.Fg,
+				Bg:        th.Bg,
+			},
+		},
+	}.Layout(gtx, th)
+}
+
+// formatDuration formats a duration in the largest sensible unit, such as
+// "3.5d" or "4.0h".
+func formatDuration(d time.Duration) string {
+	switch day := 24 * time.Hour; {
+	case d >= day:
+		return fmt.Sprintf("%.1fd", d.Hours()/24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	}
+	return fmt.Sprintf("%.0fm", d.Minutes())
+}
+
+// Charts renders a cumulative flow diagram and the weekly throughput of a
+// project.
+type Charts struct {
+	// Window selects how many days back to chart.
+	Window widget.Enum
+	List   layout.List
+	Close  widget.Clickable
+}
+
+// finalizedColor is the colour of finalized tickets in the cumulative flow
+// diagram.
+var finalizedColor = color.NRGBA{R: 158, G: 158, B: 158, A: 255}
+
+func (c *Charts) Layout(gtx C, th *material.Theme, p *kanban.Project) D {
+	if c.Window.Value == "" {
+		c.Window.Value = "30"
+	}
+	var (
+		days, _   = strconv.Atoi(c.Window.Value)
+		window    = metrics.LastDays(gtx.Now, days)
+		snapshots = metrics.Snapshots(p, window)
+		weeks     = metrics.Throughput(p, window)
+	)
+	// Stack finalized tickets at the bottom, then each stage in reverse so
+	// that tickets flow down the chart as they progress.
+	var (
+		series = [][]int{make([]int, len(snapshots))}
+		colors = []color.NRGBA{finalizedColor}
+		legend = []control.Chip{{Label: "Finalized", Color: finalizedColor}}
+	)
+	for ii, snap := range snapshots {
+		series[0][ii] = snap.Finalized
+	}
+	for ii := len(p.Stages) - 1; ii >= 0; ii-- {
+		counts := make([]int, len(snapshots))
+		for jj, snap := range snapshots {
+			counts[jj] = snap.Stages[ii]
+		}
+		col := labelColors[ii%len(labelColors)]
+		series = append(series, counts)
+		colors = append(colors, col)
+		legend = append(legend, control.Chip{Label: p.Stages[ii].Name, Color: col})
+	}
+	var (
+		throughput = make([]int, len(weeks))
+		total      = 0
+	)
+	for ii, w := range weeks {
+		throughput[ii] = w.Count
+		total += w.Count
+	}
+	var windowKeys, windowNames []string
+	for _, days := range windows {
+		windowKeys = append(windowKeys, strconv.Itoa(days))
+		windowNames = append(windowNames, fmt.Sprintf("%dd", days))
+	}
+	heading := func(text string) layout.FlexChild {
+		return layout.Rigid(func(gtx C) D {
+			return layout.Inset{Top: unit.Dp(10), Bottom: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+				return material.Body1(th, text).Layout(gtx)
+			})
+		})
+	}
+	// span labels the start and end of a chart's horizontal axis.
+	span := func(from, to time.Time) layout.Widget {
+		return func(gtx C) D {
+			return layout.Flex{
+				Axis: layout.Horizontal,
+			}.Layout(
+				gtx,
+				layout.Flexed(1, func(gtx C) D {
+					l := material.Caption(th, from.Format("Jan 2"))
+					l.Color = component.WithAlpha(l.Color, 200)
+					return l.Layout(gtx)
+				}),
+				layout.Rigid(func(gtx C) D {
+					l := material.Caption(th, to.Format("Jan 2"))
+					l.Color = component.WithAlpha(l.Color, 200)
+					return l.Layout(gtx)
+				}),
+			)
+		}
+	}
+	return control.Card{
+		Title:    "Charts",
+		Subtitle: fmt.Sprintf("Flow of tickets over the last %d days", days),
+		Body: func(gtx C) D {
+			gtx.Constraints.Min.X = gtx.Px(unit.Dp(500))
+			if gtx.Constraints.Min.X > gtx.Constraints.Max.X {
+				gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			}
+			gtx.Constraints.Max.X = gtx.Constraints.Min.X
+			return layout.Flex{
+				Axis: layout.Vertical,
+			}.Layout(
+				gtx,
+				layout.Rigid(func(gtx C) D {
+					items := []layout.FlexChild{
+						layout.Rigid(func(gtx C) D {
+							return material.Body2(th, "Window:").Layout(gtx)
+						}),
+					}
+					for ii := range windowKeys {
+						key, name := windowKeys[ii], windowNames[ii]
+						items = append(items, layout.Rigid(func(gtx C) D {
+							return material.RadioButton(th, &c.Window, key, name).Layout(gtx)
+						}))
+					}
+					return layout.Flex{
+						Axis:      layout.Horizontal,
+						Alignment: layout.Middle,
+					}.Layout(gtx, items...)
+				}),
+				heading("Cumulative flow"),
+				layout.Rigid(func(gtx C) D {
+					return control.StackedArea{
+						Series: series,
+						Colors: colors,
+						Height: unit.Dp(200),
+					}.Layout(gtx)
+				}),
+				layout.Rigid(span(window.From, window.To)),
+				layout.Rigid(func(gtx C) D {
+					c.List.Axis = layout.Horizontal
+					return c.List.Layout(gtx, len(legend), func(gtx C, ii int) D {
+						return layout.Inset{Top: unit.Dp(5), Right: unit.Dp(5)}.Layout(gtx, func(gtx C) D {
+							return legend[len(legend)-1-ii].Layout(gtx, th)
+						})
+					})
+				}),
+				heading(fmt.Sprintf("Weekly throughput: %d tickets finalized", total)),
+				layout.Rigid(func(gtx C) D {
+					return control.Bars{
+						Values: throughput,
+						Color:  th.ContrastBg,
+						Height: unit.Dp(120),
+						Gap:    unit.Dp(4),
+					}.Layout(gtx)
+				}),
+				layout.Rigid(func(gtx C) D {
+					if len(weeks) == 0 {
+						return D{}
+					}
+					return span(weeks[0].Start, weeks[len(weeks)-1].Start)(gtx)
+				}),
+			)
+		},
+		Actions: []control.Action{
+			{
+				Clickable: &c.Close,
+				Label:     "Close",
+				Fg:        th.Fg,
+				Bg:        th.Bg,
+			},
+		},
+	}.Layout(gtx, th)
+}
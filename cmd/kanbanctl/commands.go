@@ -0,0 +1,446 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/storage"
+	"github.com/google/uuid"
+	"github.com/spf13/pflag"
+)
+
+// dateFormat is how due dates are entered and displayed.
+const dateFormat = "2006-01-02"
+
+// summary describes a project in the project listing.
+type summary struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Stages    int       `json:"stages"`
+	Tickets   int       `json:"tickets"`
+	Finalized int       `json:"finalized"`
+}
+
+func listProjects(args []string) error {
+	fs := flags("projects")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	return run(printProjects)
+}
+
+// printProjects prints a summary of each active project.
+func printProjects(s storage.Storer, w io.Writer) error {
+	projects, err := s.List()
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	summaries := make([]summary, len(projects))
+	for ii, p := range projects {
+		summaries[ii] = summary{
+			ID:        p.ID,
+			Name:      p.Name,
+			Stages:    len(p.Stages),
+			Finalized: len(p.Finalized),
+		}
+		for _, stage := range p.Stages {
+			summaries[ii].Tickets += len(stage.Tickets)
+		}
+	}
+	return emit(w, summaries, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\tNAME\tSTAGES\tTICKETS\tFINALIZED\n")
+		for _, s := range summaries {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", short(s.ID), s.Name, s.Stages, s.Tickets, s.Finalized)
+		}
+	})
+}
+
+func showBoard(args []string) error {
+	fs := flags("board")
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	return run(func(s storage.Storer, w io.Writer) error {
+		return printBoard(s, w, args[0])
+	})
+}
+
+// printBoard prints the stages of the project and the tickets in each.
+func printBoard(s storage.Storer, w io.Writer, project string) error {
+	p, err := findProject(s, project)
+	if err != nil {
+		return err
+	}
+	return emit(w, p, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\n", p.Name)
+		for _, stage := range p.Stages {
+			count := fmt.Sprintf("%d", len(stage.Tickets))
+			if stage.Limit > 0 {
+				count = fmt.Sprintf("%d/%d", len(stage.Tickets), stage.Limit)
+			}
+			fmt.Fprintf(w, "\n%s (%s)\n", stage.Name, count)
+			for _, t := range stage.Tickets {
+				printTicket(w, &p, t)
+			}
+		}
+	})
+}
+
+// printTicket prints a single line describing a ticket on the board.
+func printTicket(w io.Writer, p *kanban.Project, t kanban.Ticket) {
+	var notes []string
+	if t.Priority != kanban.Normal {
+		notes = append(notes, t.Priority.String())
+	}
+	if !t.Due.IsZero() {
+		due := "due " + t.Due.Format(dateFormat)
+		if t.Overdue(time.Now()) {
+			due = "overdue " + t.Due.Format(dateFormat)
+		}
+		notes = append(notes, due)
+	}
+	if done, total := t.Progress(); total > 0 {
+		notes = append(notes, fmt.Sprintf("%d/%d done", done, total))
+	}
+	if p.Blocked(t) {
+		notes = append(notes, "blocked")
+	}
+	for _, l := range p.LabelsFor(t) {
+		notes = append(notes, "#"+l.Name)
+	}
+	for _, person := range p.AssigneesFor(t) {
+		notes = append(notes, "@"+person.Name)
+	}
+	fmt.Fprintf(w, "  %s\t%s\t%s\n", short(t.ID), t.Title, strings.Join(notes, ", "))
+}
+
+func addTicket(args []string) error {
+	var (
+		fs    = flags("add")
+		stage = fs.String("stage", "", "name of the stage to add the ticket to")
+		f     = ticketFlags(fs)
+	)
+	args, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	return run(func(s storage.Storer, w io.Writer) error {
+		return add(s, w, args[0], *stage, args[1], f)
+	})
+}
+
+// add a ticket with the given title to the named stage, or the first stage
+// if the name is empty.
+func add(s storage.Storer, w io.Writer, project, stage, title string, f ticketFields) error {
+	p, err := findProject(s, project)
+	if err != nil {
+		return err
+	}
+	if len(p.Stages) == 0 {
+		return fmt.Errorf("project %q has no stages", p.Name)
+	}
+	into := p.Stages[0]
+	if stage != "" {
+		found, ok := findStage(&p, stage)
+		if !ok {
+			return fmt.Errorf("stage does not exist: %q", stage)
+		}
+		into = found
+	}
+	t := kanban.Ticket{
+		ID:      uuid.New(),
+		Title:   title,
+		Created: time.Now(),
+	}
+	if err := f.apply(&p, &t); err != nil {
+		return err
+	}
+	if err := p.AssignTicket(into.ID, t); err != nil {
+		return err
+	}
+	if err := s.Save(p); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	t, _ = p.FindTicket(t.ID)
+	return emit(w, t, func(w io.Writer) {
+		fmt.Fprintf(w, "added %s %q to %s\n", short(t.ID), t.Title, into.Name)
+	})
+}
+
+func editTicket(args []string) error {
+	var (
+		fs    = flags("edit")
+		title = fs.String("title", "", "new title of the ticket")
+		f     = ticketFlags(fs)
+	)
+	args, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	return run(func(s storage.Storer, w io.Writer) error {
+		return edit(s, w, args[0], args[1], *title, f)
+	})
+}
+
+// edit the ticket, renaming it unless title is empty.
+func edit(s storage.Storer, w io.Writer, project, ticket, title string, f ticketFields) error {
+	p, err := findProject(s, project)
+	if err != nil {
+		return err
+	}
+	t, err := findTicket(&p, ticket)
+	if err != nil {
+		return err
+	}
+	t = t.Clone()
+	if title != "" {
+		t.Title = title
+	}
+	if err := f.apply(&p, &t); err != nil {
+		return err
+	}
+	if err := p.UpdateTicket(t); err != nil {
+		return err
+	}
+	if err := s.Save(p); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	t, _ = p.FindTicket(t.ID)
+	return emit(w, t, func(w io.Writer) {
+		fmt.Fprintf(w, "edited %s %q\n", short(t.ID), t.Title)
+	})
+}
+
+func progressTicket(args []string) error {
+	return moveTicket("progress", args, (*kanban.Project).ProgressTicket)
+}
+
+func regressTicket(args []string) error {
+	return moveTicket("regress", args, (*kanban.Project).RegressTicket)
+}
+
+func moveTicket(name string, args []string, step func(*kanban.Project, kanban.Ticket) error) error {
+	fs := flags(name)
+	args, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	return run(func(s storage.Storer, w io.Writer) error {
+		return move(s, w, args[0], args[1], step)
+	})
+}
+
+// move a ticket one stage using step.
+// Tickets that cannot move any further are reported as an error, rather than
+// silently staying put.
+func move(s storage.Storer, w io.Writer, project, ticket string, step func(*kanban.Project, kanban.Ticket) error) error {
+	p, err := findProject(s, project)
+	if err != nil {
+		return err
+	}
+	t, err := findTicket(&p, ticket)
+	if err != nil {
+		return err
+	}
+	var (
+		from = *p.StageForTicket(t)
+		past = p.PastGate(t)
+	)
+	if err := step(&p, t); err != nil {
+		return err
+	}
+	to := *p.StageForTicket(t)
+	if to.ID == from.ID {
+		return fmt.Errorf("%q cannot move past %s", t.Title, from.Name)
+	}
+	if !past && p.PastGate(t) && p.Blocked(t) {
+		fmt.Fprintf(os.Stderr, "warning: %q moved past the gate while blocked\n", t.Title)
+	}
+	if err := s.Save(p); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	t, _ = p.FindTicket(t.ID)
+	return emit(w, t, func(w io.Writer) {
+		fmt.Fprintf(w, "moved %s %q from %s to %s\n", short(t.ID), t.Title, from.Name, to.Name)
+	})
+}
+
+func finalizeTicket(args []string) error {
+	fs := flags("finalize")
+	args, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	return run(func(s storage.Storer, w io.Writer) error {
+		return finalize(s, w, args[0], args[1])
+	})
+}
+
+// finalize a ticket, moving it into the archive.
+func finalize(s storage.Storer, w io.Writer, project, ticket string) error {
+	p, err := findProject(s, project)
+	if err != nil {
+		return err
+	}
+	t, err := findTicket(&p, ticket)
+	if err != nil {
+		return err
+	}
+	if err := p.FinalizeTicket(t); err != nil {
+		return err
+	}
+	if err := s.Save(p); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	for _, f := range p.Finalized {
+		if f.ID == t.ID {
+			t = f
+		}
+	}
+	return emit(w, t, func(w io.Writer) {
+		fmt.Fprintf(w, "finalized %s %q\n", short(t.ID), t.Title)
+	})
+}
+
+// ticketFields holds the flags shared by commands that write a ticket.
+type ticketFields struct {
+	summary  *string
+	details  *string
+	priority *string
+	due      *string
+	labels   *[]string
+	assign   *[]string
+	changed  func(name string) bool
+}
+
+// ticketFlags registers the flags for the editable fields of a ticket.
+func ticketFlags(fs *pflag.FlagSet) ticketFields {
+	return ticketFields{
+		summary:  fs.String("summary", "", "one line summary"),
+		details:  fs.String("details", "", "long form details"),
+		priority: fs.String("priority", "", "one of critical, high, normal or low"),
+		due:      fs.String("due", "", "due date as YYYY-MM-DD, empty to clear"),
+		labels:   fs.StringSlice("label", nil, "names of labels to tag the ticket with, replacing any others"),
+		assign:   fs.StringSlice("assign", nil, "names of people to assign the ticket to, replacing any others"),
+		changed:  fs.Changed,
+	}
+}
+
+// apply the flags that were set to the ticket.
+func (f ticketFields) apply(p *kanban.Project, t *kanban.Ticket) error {
+	if f.changed("summary") {
+		t.Summary = *f.summary
+	}
+	if f.changed("details") {
+		t.Details = *f.details
+	}
+	if f.changed("priority") {
+		priority, err := kanban.ParsePriority(strings.ToLower(*f.priority))
+		if err != nil {
+			return err
+		}
+		t.Priority = priority
+	}
+	if f.changed("due") {
+		t.Due = time.Time{}
+		if text := strings.TrimSpace(*f.due); text != "" {
+			due, err := time.ParseInLocation(dateFormat, text, time.Local)
+			if err != nil {
+				return fmt.Errorf("due date: %w", err)
+			}
+			t.Due = due
+		}
+	}
+	if f.changed("label") {
+		t.Labels = nil
+		for _, name := range *f.labels {
+			label, ok := findLabel(p, name)
+			if !ok {
+				return fmt.Errorf("label does not exist: %q", name)
+			}
+			t.Tag(label.ID)
+		}
+	}
+	if f.changed("assign") {
+		t.Assignees = nil
+		for _, name := range *f.assign {
+			person, ok := p.FindPersonByName(name)
+			if !ok {
+				return fmt.Errorf("person does not exist: %q", name)
+			}
+			t.Assign(person.ID)
+		}
+	}
+	return nil
+}
+
+// findProject finds an active project by ID, unique ID prefix, or name.
+func findProject(s storage.Storer, ref string) (kanban.Project, error) {
+	projects, err := s.List()
+	if err != nil {
+		return kanban.Project{}, fmt.Errorf("listing projects: %w", err)
+	}
+	var matches []kanban.Project
+	for _, p := range projects {
+		if p.ID.String() == ref || strings.EqualFold(p.Name, ref) {
+			return p, nil
+		}
+		if strings.HasPrefix(p.ID.String(), strings.ToLower(ref)) {
+			matches = append(matches, p)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return kanban.Project{}, fmt.Errorf("project does not exist: %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return kanban.Project{}, fmt.Errorf("%q matches %d projects", ref, len(matches))
+}
+
+// findTicket finds a ticket on the board by ID, unique ID prefix, or title.
+func findTicket(p *kanban.Project, ref string) (kanban.Ticket, error) {
+	var matches []kanban.Ticket
+	for _, stage := range p.Stages {
+		for _, t := range stage.Tickets {
+			if t.ID.String() == ref {
+				return t, nil
+			}
+			if strings.HasPrefix(t.ID.String(), strings.ToLower(ref)) || strings.EqualFold(t.Title, ref) {
+				matches = append(matches, t)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return kanban.Ticket{}, fmt.Errorf("ticket does not exist: %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return kanban.Ticket{}, fmt.Errorf("%q matches %d tickets", ref, len(matches))
+}
+
+// findStage finds a stage by name.
+func findStage(p *kanban.Project, name string) (kanban.Stage, bool) {
+	for _, s := range p.Stages {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return kanban.Stage{}, false
+}
+
+// findLabel finds a label by name.
+func findLabel(p *kanban.Project, name string) (kanban.Label, bool) {
+	for _, l := range p.Labels {
+		if strings.EqualFold(l.Name, name) {
+			return l, true
+		}
+	}
+	return kanban.Label{}, false
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/storage/mem"
+	"github.com/google/uuid"
+)
+
+// setup returns a storer holding the project "Work" with the stages "todo",
+// "doing" and "done", and a ticket "write tests" in "todo".
+func setup(t *testing.T) (*mem.Storer, kanban.Project, kanban.Ticket) {
+	t.Helper()
+	p := kanban.Project{ID: uuid.New(), Name: "Work"}
+	todo := p.MakeStage("todo")
+	p.MakeStage("doing")
+	p.MakeStage("done")
+	ticket := kanban.Ticket{ID: uuid.New(), Title: "write tests"}
+	if err := p.AssignTicket(todo, ticket); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	s := mem.New()
+	if err := s.Create(p); err != nil {
+		t.Fatalf("creating project: %v", err)
+	}
+	return s, p, ticket
+}
+
+// stored returns the project as saved in s.
+func stored(t *testing.T, s *mem.Storer, id uuid.UUID) kanban.Project {
+	t.Helper()
+	p, ok, err := s.Find(id)
+	if err != nil || !ok {
+		t.Fatalf("finding project: %v, %v", ok, err)
+	}
+	return p
+}
+
+// stage returns the name of the stage holding the ticket in the project as
+// saved in s.
+func stage(t *testing.T, s *mem.Storer, id uuid.UUID, ticket kanban.Ticket) string {
+	t.Helper()
+	p := stored(t, s, id)
+	return p.StageForTicket(ticket).Name
+}
+
+func TestFindProject(t *testing.T) {
+	s := mem.New()
+	var (
+		first  = kanban.Project{ID: uuid.MustParse("abcd1111-0000-0000-0000-000000000000"), Name: "First"}
+		second = kanban.Project{ID: uuid.MustParse("abcd2222-0000-0000-0000-000000000000"), Name: "Second"}
+	)
+	for _, p := range []kanban.Project{first, second} {
+		if err := s.Create(p); err != nil {
+			t.Fatalf("creating project: %v", err)
+		}
+	}
+	for _, tt := range []struct {
+		name string
+		ref  string
+		want uuid.UUID
+		err  string
+	}{
+		{name: "id", ref: second.ID.String(), want: second.ID},
+		{name: "name", ref: "second", want: second.ID},
+		{name: "prefix", ref: "ABCD1", want: first.ID},
+		{name: "ambiguous prefix", ref: "abcd", err: `"abcd" matches 2 projects`},
+		{name: "missing", ref: "third", err: `project does not exist: "third"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := findProject(s, tt.ref)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("want error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("finding project: %v", err)
+			}
+			if p.ID != tt.want {
+				t.Fatalf("want project %v, got %v", tt.want, p.ID)
+			}
+		})
+	}
+}
+
+func TestFindTicket(t *testing.T) {
+	var p kanban.Project
+	todo, doing := p.MakeStage("todo"), p.MakeStage("doing")
+	var (
+		first  = kanban.Ticket{ID: uuid.MustParse("abcd1111-0000-0000-0000-000000000000"), Title: "First"}
+		second = kanban.Ticket{ID: uuid.MustParse("abcd2222-0000-0000-0000-000000000000"), Title: "Same"}
+		third  = kanban.Ticket{ID: uuid.MustParse("ef001111-0000-0000-0000-000000000000"), Title: "Same"}
+		closed = kanban.Ticket{ID: uuid.MustParse("99991111-0000-0000-0000-000000000000"), Title: "Closed"}
+	)
+	for _, ticket := range []kanban.Ticket{first, second, closed} {
+		if err := p.AssignTicket(todo, ticket); err != nil {
+			t.Fatalf("assigning ticket: %v", err)
+		}
+	}
+	if err := p.AssignTicket(doing, third); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	if err := p.FinalizeTicket(closed); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	for _, tt := range []struct {
+		name string
+		ref  string
+		want uuid.UUID
+		err  string
+	}{
+		{name: "id", ref: third.ID.String(), want: third.ID},
+		{name: "title", ref: "first", want: first.ID},
+		{name: "prefix", ref: "EF00", want: third.ID},
+		{name: "ambiguous prefix", ref: "abcd", err: `"abcd" matches 2 tickets`},
+		{name: "ambiguous title", ref: "same", err: `"same" matches 2 tickets`},
+		{name: "finalized", ref: "closed", err: `ticket does not exist: "closed"`},
+		{name: "missing", ref: "fourth", err: `ticket does not exist: "fourth"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket, err := findTicket(&p, tt.ref)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("want error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("finding ticket: %v", err)
+			}
+			if ticket.ID != tt.want {
+				t.Fatalf("want ticket %v, got %v", tt.want, ticket.ID)
+			}
+		})
+	}
+}
+
+func TestMove(t *testing.T) {
+	s, p, ticket := setup(t)
+	var out bytes.Buffer
+	if err := move(s, &out, "work", "write tests", (*kanban.Project).ProgressTicket); err != nil {
+		t.Fatalf("progressing ticket: %v", err)
+	}
+	if want := "moved " + short(ticket.ID) + ` "write tests" from todo to doing`; !strings.Contains(out.String(), want) {
+		t.Fatalf("want %q printed, got %q", want, out.String())
+	}
+	if got := stage(t, s, p.ID, ticket); got != "doing" {
+		t.Fatalf("want move saved, got ticket in %s", got)
+	}
+}
+
+func TestMoveErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		project string
+		ticket  string
+		step    func(*kanban.Project, kanban.Ticket) error
+		setup   func(t *testing.T, p *kanban.Project)
+		err     string
+	}{
+		{
+			name:    "missing project",
+			project: "home",
+			ticket:  "write tests",
+			step:    (*kanban.Project).ProgressTicket,
+			err:     `project does not exist: "home"`,
+		},
+		{
+			name:    "missing ticket",
+			project: "work",
+			ticket:  "write docs",
+			step:    (*kanban.Project).ProgressTicket,
+			err:     `ticket does not exist: "write docs"`,
+		},
+		{
+			name:    "first stage",
+			project: "work",
+			ticket:  "write tests",
+			step:    (*kanban.Project).RegressTicket,
+			err:     `"write tests" cannot move past todo`,
+		},
+		{
+			name:    "hard limit",
+			project: "work",
+			ticket:  "write tests",
+			step:    (*kanban.Project).ProgressTicket,
+			setup: func(t *testing.T, p *kanban.Project) {
+				p.Limits = kanban.Hard
+				p.Stages[1].Limit = 1
+				if err := p.AssignTicket(p.Stages[1].ID, kanban.Ticket{ID: uuid.New(), Title: "other"}); err != nil {
+					t.Fatalf("assigning ticket: %v", err)
+				}
+			},
+			err: kanban.LimitError{Stage: "doing", Limit: 1}.Error(),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, p, ticket := setup(t)
+			if tt.setup != nil {
+				tt.setup(t, &p)
+				if err := s.Save(p); err != nil {
+					t.Fatalf("saving project: %v", err)
+				}
+			}
+			var out bytes.Buffer
+			err := move(s, &out, tt.project, tt.ticket, tt.step)
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("want error %q, got %v", tt.err, err)
+			}
+			if out.Len() != 0 {
+				t.Fatalf("want nothing printed, got %q", out.String())
+			}
+			if got := stage(t, s, p.ID, ticket); got != "todo" {
+				t.Fatalf("want ticket left in todo, got %s", got)
+			}
+		})
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	s, p, ticket := setup(t)
+	var out bytes.Buffer
+	if err := finalize(s, &out, p.ID.String(), short(ticket.ID)); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	if want := "finalized " + short(ticket.ID) + ` "write tests"`; !strings.Contains(out.String(), want) {
+		t.Fatalf("want %q printed, got %q", want, out.String())
+	}
+	if got := stored(t, s, p.ID); len(got.Finalized) != 1 || got.Finalized[0].ID != ticket.ID {
+		t.Fatalf("want finalized ticket saved")
+	}
+	out.Reset()
+	err := finalize(s, &out, "work", "write tests")
+	if want := `ticket does not exist: "write tests"`; err == nil || err.Error() != want {
+		t.Fatalf("want error %q finalizing twice, got %v", want, err)
+	}
+}
+
+func TestFinalizeOpenChecklist(t *testing.T) {
+	s, p, ticket := setup(t)
+	p.StrictChecklists = true
+	ticket.AddItem("step")
+	if err := p.UpdateTicket(ticket); err != nil {
+		t.Fatalf("updating ticket: %v", err)
+	}
+	if err := s.Save(p); err != nil {
+		t.Fatalf("saving project: %v", err)
+	}
+	var out bytes.Buffer
+	err := finalize(s, &out, "work", "write tests")
+	if _, ok := err.(kanban.ChecklistError); !ok {
+		t.Fatalf("want ChecklistError, got %v", err)
+	}
+	if got := stored(t, s, p.ID); len(got.Finalized) != 0 || out.Len() != 0 {
+		t.Fatalf("want nothing finalized or printed")
+	}
+}
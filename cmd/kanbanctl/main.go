@@ -0,0 +1,212 @@
+// Command kanbanctl manipulates Kanban boards from the terminal, without
+// opening a window.
+//
+// It works against the same database file as the kanban app, so the app must
+// be closed while kanbanctl runs.
+//
+// Usage:
+//
+//	kanbanctl <command> [flags] [args]
+//
+// The tui command opens a full-screen board, navigated with the arrow keys in
+// the same way as the app.
+//
+// The serve command exposes the database as a REST API, see package server
+// for the routes.
+//
+// Projects are referred to by name or ID, and tickets by title, ID, or a
+// unique prefix of their ID as shown on the board.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+
+	"git.sr.ht/~jackmordaunt/kanban/storage"
+	"git.sr.ht/~jackmordaunt/kanban/storage/lazy"
+)
+
+var (
+	DB   string
+	JSON bool
+)
+
+// command is a subcommand of kanbanctl.
+type command struct {
+	// Args describes the positional arguments.
+	Args    string
+	Summary string
+	Run     func(args []string) error
+}
+
+// commands by name.
+// Initialised in init since the commands refer back to it for their usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"projects": {
+			Summary: "list projects",
+			Run:     listProjects,
+		},
+		"board": {
+			Args:    "<project>",
+			Summary: "show the stages and tickets of a project",
+			Run:     showBoard,
+		},
+		"add": {
+			Args:    "<project> <title>",
+			Summary: "add a ticket, to the first stage unless --stage is given",
+			Run:     addTicket,
+		},
+		"edit": {
+			Args:    "<project> <ticket>",
+			Summary: "edit the fields of a ticket",
+			Run:     editTicket,
+		},
+		"progress": {
+			Args:    "<project> <ticket>",
+			Summary: "move a ticket to the next stage",
+			Run:     progressTicket,
+		},
+		"regress": {
+			Args:    "<project> <ticket>",
+			Summary: "move a ticket to the previous stage",
+			Run:     regressTicket,
+		},
+		"finalize": {
+			Args:    "<project> <ticket>",
+			Summary: "move a ticket into the finalized archive",
+			Run:     finalizeTicket,
+		},
+		"serve": {
+			Summary: "serve projects as a REST API with JSON bodies",
+			Run:     serve,
+		},
+		"tui": {
+			Args:    "<project>",
+			Summary: "open a full-screen board in the terminal",
+			Run:     runBoard,
+		},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "kanbanctl: unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	if err := cmd.Run(os.Args[2:]); err != nil {
+		if err == pflag.ErrHelp {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "kanbanctl %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: kanbanctl <command> [flags] [args]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", name, commands[name].Args, commands[name].Summary)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nrun \"kanbanctl <command> --help\" for the flags of a command\n")
+}
+
+// flags returns the flag set for the named command, including the flags
+// common to every command.
+func flags(name string) *pflag.FlagSet {
+	fs := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	fs.StringVar(&DB, "db", defaultDB(), "path to the database file")
+	fs.BoolVar(&JSON, "json", false, "print results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: kanbanctl %s [flags] %s\n\n%s\n\nflags:\n", name, commands[name].Args, commands[name].Summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse the arguments of a command, requiring exactly n positional
+// arguments.
+func parse(fs *pflag.FlagSet, args []string, n int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != n {
+		fs.Usage()
+		return nil, fmt.Errorf("expected %d arguments, got %d", n, fs.NArg())
+	}
+	return fs.Args(), nil
+}
+
+// defaultDB returns the database file the kanban app uses.
+//
+// This mirrors app.DataDir on desktop platforms, without depending on the
+// windowing code that comes with it.
+func defaultDB() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "kanban.db"
+	}
+	return filepath.Join(dir, "kanban.db")
+}
+
+// open the database file.
+func open() (*lazy.Storer, error) {
+	s, err := lazy.Open(DB)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", DB, err)
+	}
+	return s, nil
+}
+
+// run f against the database file, writing its output to stdout.
+func run(f func(s storage.Storer, w io.Writer) error) error {
+	s, err := open()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return f(s, os.Stdout)
+}
+
+// emit prints v to w as JSON when requested, otherwise prints it for humans
+// using the provided function.
+// Human output is written through a tabwriter so that columns line up.
+func emit(w io.Writer, v interface{}, human func(w io.Writer)) error {
+	if JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	human(tw)
+	return tw.Flush()
+}
+
+// short abbreviates an ID for display.
+func short(id fmt.Stringer) string {
+	return strings.SplitN(id.String(), "-", 2)[0]
+}
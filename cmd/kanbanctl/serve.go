@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"git.sr.ht/~jackmordaunt/kanban/server"
+	"git.sr.ht/~jackmordaunt/kanban/storage"
+)
+
+func serve(args []string) error {
+	var (
+		fs   = flags("serve")
+		addr = fs.String("addr", "localhost:8080", "address to listen on")
+	)
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	return run(func(s storage.Storer, _ io.Writer) error {
+		fmt.Fprintf(os.Stderr, "serving %s on http://%s\n", DB, *addr)
+		return http.ListenAndServe(*addr, server.New(s))
+	})
+}
@@ -0,0 +1,24 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import (
+	"errors"
+	"runtime"
+)
+
+// terminal is unsupported on this platform.
+type terminal struct{}
+
+func raw(fd int) (*terminal, error) {
+	return nil, errors.New("terminal board is not supported on " + runtime.GOOS)
+}
+
+func (t *terminal) restore() error {
+	return nil
+}
+
+func (t *terminal) size() (int, int, error) {
+	return 0, 0, errors.New("terminal board is not supported on " + runtime.GOOS)
+}
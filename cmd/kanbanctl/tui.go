@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/focus"
+	"git.sr.ht/~jackmordaunt/kanban/storage"
+	"github.com/google/uuid"
+)
+
+// Keys as read from a terminal in raw mode.
+const (
+	keyUp        = "\x1b[A"
+	keyDown      = "\x1b[B"
+	keyRight     = "\x1b[C"
+	keyLeft      = "\x1b[D"
+	keyEnter     = "\r"
+	keyEscape    = "\x1b"
+	keyBackspace = "\x7f"
+	keyCtrlH     = "\x08"
+	keyCtrlC     = "\x03"
+)
+
+// help lists the keys the board responds to.
+const help = "arrows move  enter edit  a add  < > regress/progress  f finalize  r reload  q quit"
+
+func runBoard(args []string) error {
+	fs := flags("tui")
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	return run(func(s storage.Storer, w io.Writer) error {
+		p, err := findProject(s, args[0])
+		if err != nil {
+			return err
+		}
+		term, err := raw(int(os.Stdin.Fd()))
+		if err != nil {
+			return err
+		}
+		defer term.restore()
+		b := board{
+			Storage: s,
+			Project: p,
+			term:    term,
+			in:      os.Stdin,
+			out:     bufio.NewWriter(w),
+		}
+		return b.Run()
+	})
+}
+
+// board is a full-screen terminal board for a single project.
+//
+// Focus moves exactly as it does in the GUI, and every change is written
+// through the Storer as soon as it is made.
+type board struct {
+	Storage storage.Storer
+	Project kanban.Project
+	Focus   focus.Focus
+
+	term *terminal
+	in   io.Reader
+	out  *bufio.Writer
+	// status is a one line message, such as an error from the last action.
+	status string
+	// prompt is the line being edited, nil when not editing.
+	prompt *prompt
+}
+
+// prompt is a single line of text being entered at the bottom of the screen.
+type prompt struct {
+	Label string
+	Text  []rune
+}
+
+// Run the board until the user quits.
+func (b *board) Run() error {
+	// Switch to the alternate screen so that the shell is left as it was.
+	fmt.Fprint(b.out, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(b.out, "\x1b[?25h\x1b[?1049l")
+		b.out.Flush()
+	}()
+	b.Focus.Move(&b.Project, focus.NextTicket)
+	for {
+		if err := b.draw(); err != nil {
+			return err
+		}
+		key, err := b.read()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "q", keyCtrlC:
+			return nil
+		case keyUp, "k":
+			b.Focus.Move(&b.Project, focus.PreviousTicket)
+		case keyDown, "j":
+			b.Focus.Move(&b.Project, focus.NextTicket)
+		case keyRight, "l":
+			b.Focus.Move(&b.Project, focus.NextStage)
+		case keyLeft, "h":
+			b.Focus.Move(&b.Project, focus.PreviousStage)
+		case keyEnter:
+			b.edit()
+		case "a":
+			b.add()
+		case ">", ".":
+			b.move((*kanban.Project).ProgressTicket)
+		case "<", ",":
+			b.move((*kanban.Project).RegressTicket)
+		case "f":
+			b.finalize()
+		case "r":
+			b.reload()
+		}
+	}
+}
+
+// edit the title of the focused ticket.
+func (b *board) edit() {
+	if b.Focus.T == nil {
+		return
+	}
+	title, ok := b.ask("Title: ", b.Focus.T.Title)
+	if !ok || title == b.Focus.T.Title {
+		return
+	}
+	t := b.Focus.T.Clone()
+	t.Title = title
+	if err := b.Project.UpdateTicket(t); err != nil {
+		b.status = err.Error()
+		return
+	}
+	b.save(t.ID)
+}
+
+// add a ticket to the focused stage.
+func (b *board) add() {
+	if len(b.Project.Stages) == 0 {
+		return
+	}
+	stage := b.Project.Stages[b.Focus.Stage]
+	title, ok := b.ask(fmt.Sprintf("New ticket in %s: ", stage.Name), "")
+	if !ok || title == "" {
+		return
+	}
+	t := kanban.Ticket{
+		ID:      uuid.New(),
+		Title:   title,
+		Created: time.Now(),
+	}
+	if err := b.Project.AssignTicket(stage.ID, t); err != nil {
+		b.status = err.Error()
+		return
+	}
+	b.save(t.ID)
+}
+
+// move the focused ticket to an adjacent stage.
+func (b *board) move(move func(*kanban.Project, kanban.Ticket) error) {
+	if b.Focus.T == nil {
+		return
+	}
+	t := *b.Focus.T
+	past := b.Project.PastGate(t)
+	if err := move(&b.Project, t); err != nil {
+		b.status = err.Error()
+		return
+	}
+	b.save(t.ID)
+	if !past && b.Project.PastGate(t) && b.Project.Blocked(t) {
+		b.status = fmt.Sprintf("%q moved past the gate while blocked", t.Title)
+	}
+}
+
+// finalize the focused ticket.
+func (b *board) finalize() {
+	if b.Focus.T == nil {
+		return
+	}
+	t := *b.Focus.T
+	if err := b.Project.FinalizeTicket(t); err != nil {
+		b.status = err.Error()
+		return
+	}
+	b.save(uuid.Nil)
+	b.status = fmt.Sprintf("finalized %q", t.Title)
+}
+
+// reload the project from storage, discarding nothing since every change is
+// saved as it is made.
+func (b *board) reload() {
+	p, ok, err := b.Storage.Find(b.Project.ID)
+	if err != nil {
+		b.status = err.Error()
+		return
+	}
+	if !ok {
+		b.status = "project no longer exists"
+		return
+	}
+	var focused uuid.UUID
+	if b.Focus.T != nil {
+		focused = b.Focus.T.ID
+	}
+	b.Project = p
+	b.refocus(focused)
+	b.status = "reloaded"
+}
+
+// save the project, then focus the ticket with the given ID.
+// Saving invalidates the focus because the project's tickets may have moved
+// in memory.
+func (b *board) save(id uuid.UUID) {
+	b.status = ""
+	// Keep the revision storage advances to, so the next save is not taken
+	// for a stale one.
+	saved := []kanban.Project{b.Project}
+	if err := b.Storage.Save(saved...); err != nil {
+		b.status = fmt.Sprintf("saving: %v", err)
+	}
+	b.Project.Revision = saved[0].Revision
+	b.refocus(id)
+}
+
+// refocus the ticket with the given ID, falling back to the first ticket of
+// the focused stage.
+func (b *board) refocus(id uuid.UUID) {
+	if t, ok := b.Project.FindTicket(id); ok && b.Focus.To(&b.Project, t) {
+		return
+	}
+	b.Focus.Ticket = 0
+	b.Focus.Clear()
+	b.Focus.Move(&b.Project, focus.NextTicket)
+}
+
+// ask for a line of text, starting with the given text.
+// False means the prompt was cancelled.
+func (b *board) ask(label, text string) (string, bool) {
+	b.prompt = &prompt{Label: label, Text: []rune(text)}
+	defer func() { b.prompt = nil }()
+	for {
+		if err := b.draw(); err != nil {
+			return "", false
+		}
+		key, err := b.read()
+		if err != nil {
+			return "", false
+		}
+		switch key {
+		case keyEnter:
+			return strings.TrimSpace(string(b.prompt.Text)), true
+		case keyEscape, keyCtrlC:
+			return "", false
+		case keyBackspace, keyCtrlH:
+			if n := len(b.prompt.Text); n > 0 {
+				b.prompt.Text = b.prompt.Text[:n-1]
+			}
+		default:
+			if strings.HasPrefix(key, keyEscape) {
+				// Ignore cursor keys and other sequences.
+				continue
+			}
+			for _, r := range key {
+				if unicode.IsPrint(r) {
+					b.prompt.Text = append(b.prompt.Text, r)
+				}
+			}
+		}
+	}
+}
+
+// read the next key press.
+// Escape sequences, such as for the arrow keys, arrive as a single read.
+func (b *board) read() (string, error) {
+	var buf [32]byte
+	n, err := b.in.Read(buf[:])
+	if err != nil {
+		return "", err
+	}
+	key := string(buf[:n])
+	// Some terminals send cursor keys in application mode.
+	if strings.HasPrefix(key, "\x1bO") && len(key) == 3 {
+		key = "\x1b[" + key[2:]
+	}
+	return key, nil
+}
+
+// draw the board to the terminal.
+func (b *board) draw() error {
+	cols, rows, err := b.term.size()
+	if err != nil || cols == 0 || rows == 0 {
+		cols, rows = 80, 24
+	}
+	var lines []string
+	lines = append(lines, bold(fit(b.Project.Name, cols)))
+	lines = append(lines, "")
+	stages := b.Project.Stages
+	width := cols
+	if len(stages) > 0 {
+		width = cols / len(stages)
+	}
+	if width < 2 {
+		width = 2
+	}
+	var header, rule strings.Builder
+	for ii, stage := range stages {
+		count := fmt.Sprintf("%d", len(stage.Tickets))
+		if stage.Limit > 0 {
+			count = fmt.Sprintf("%d/%d", len(stage.Tickets), stage.Limit)
+		}
+		name := pad(fit(fmt.Sprintf("%s (%s)", stage.Name, count), width-1), width)
+		if ii == b.Focus.Stage {
+			name = bold(name)
+		}
+		header.WriteString(name)
+		rule.WriteString(strings.Repeat("─", width-1) + " ")
+	}
+	lines = append(lines, header.String(), rule.String())
+	// Leave room for the header above and the status and help lines below.
+	body := rows - len(lines) - 2
+	for row := 0; row < body; row++ {
+		var line strings.Builder
+		for ii, stage := range stages {
+			offset := 0
+			if ii == b.Focus.Stage && b.Focus.Ticket >= body {
+				offset = b.Focus.Ticket - body + 1
+			}
+			jj := row + offset
+			if jj >= len(stage.Tickets) {
+				line.WriteString(strings.Repeat(" ", width))
+				continue
+			}
+			t := stage.Tickets[jj]
+			cell := pad(fit(b.describe(t), width-1), width-1)
+			if b.Focus.T != nil && b.Focus.T.ID == t.ID {
+				cell = reverse(cell)
+			}
+			line.WriteString(cell + " ")
+		}
+		lines = append(lines, line.String())
+	}
+	status := b.status
+	if b.prompt != nil {
+		status = b.prompt.Label + string(b.prompt.Text) + "_"
+	}
+	lines = append(lines, fit(status, cols), faint(fit(help, cols)))
+	fmt.Fprint(b.out, "\x1b[H")
+	for ii, line := range lines {
+		fmt.Fprint(b.out, line, "\x1b[K")
+		if ii < len(lines)-1 {
+			fmt.Fprint(b.out, "\r\n")
+		}
+	}
+	fmt.Fprint(b.out, "\x1b[J")
+	return b.out.Flush()
+}
+
+// describe a ticket in a single line, marking its priority and whether it
+// needs attention.
+func (b *board) describe(t kanban.Ticket) string {
+	var text strings.Builder
+	switch t.Priority {
+	case kanban.Critical:
+		text.WriteString("!! ")
+	case kanban.High:
+		text.WriteString("! ")
+	}
+	text.WriteString(t.Title)
+	if t.Overdue(time.Now()) {
+		text.WriteString(" (overdue)")
+	}
+	if b.Project.Blocked(t) {
+		text.WriteString(" (blocked)")
+	}
+	return text.String()
+}
+
+// fit truncates text to at most n runes, marking truncation with an
+// ellipsis.
+func fit(text string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	r := []rune(text)
+	return string(r[:n-1]) + "…"
+}
+
+// pad text with spaces to n runes.
+func pad(text string, n int) string {
+	if count := utf8.RuneCountInString(text); count < n {
+		return text + strings.Repeat(" ", n-count)
+	}
+	return text
+}
+
+func bold(text string) string {
+	return "\x1b[1m" + text + "\x1b[0m"
+}
+
+func faint(text string) string {
+	return "\x1b[2m" + text + "\x1b[0m"
+}
+
+func reverse(text string) string {
+	return "\x1b[7m" + text + "\x1b[0m"
+}
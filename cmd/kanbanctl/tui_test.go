@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/focus"
+)
+
+func TestBoardMove(t *testing.T) {
+	s, p, ticket := setup(t)
+	b := board{Storage: s, Project: p}
+	b.Focus.Move(&b.Project, focus.NextTicket)
+	b.move((*kanban.Project).RegressTicket)
+	if got := stage(t, s, p.ID, ticket); got != "todo" || b.status != "" {
+		t.Fatalf("want ticket at the first stage left alone, got %s with status %q", got, b.status)
+	}
+	b.move((*kanban.Project).ProgressTicket)
+	if got := stage(t, s, p.ID, ticket); got != "doing" {
+		t.Fatalf("want move saved, got ticket in %s", got)
+	}
+	if b.Focus.T == nil || b.Focus.T.ID != ticket.ID {
+		t.Fatalf("want moved ticket still focused")
+	}
+	b.Project.Limits = kanban.Hard
+	b.Project.Stages[2].Limit = 1
+	if err := b.Project.AssignTicket(b.Project.Stages[2].ID, kanban.Ticket{Title: "other"}); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	b.move((*kanban.Project).ProgressTicket)
+	if want := (kanban.LimitError{Stage: "done", Limit: 1}).Error(); b.status != want {
+		t.Fatalf("want status %q, got %q", want, b.status)
+	}
+	if got := stage(t, s, p.ID, ticket); got != "doing" {
+		t.Fatalf("want ticket left in doing, got %s", got)
+	}
+}
+
+func TestBoardFinalize(t *testing.T) {
+	s, p, ticket := setup(t)
+	b := board{Storage: s, Project: p}
+	b.Project.StrictChecklists = true
+	b.Focus.Move(&b.Project, focus.NextTicket)
+	open := b.Focus.T.Clone()
+	open.AddItem("step")
+	if err := b.Project.UpdateTicket(open); err != nil {
+		t.Fatalf("updating ticket: %v", err)
+	}
+	b.refocus(ticket.ID)
+	b.finalize()
+	if want := (kanban.ChecklistError{Ticket: "write tests", Open: 1}).Error(); b.status != want {
+		t.Fatalf("want status %q, got %q", want, b.status)
+	}
+	if len(stored(t, s, p.ID).Finalized) != 0 {
+		t.Fatalf("want nothing finalized")
+	}
+	b.Project.StrictChecklists = false
+	b.finalize()
+	if got := stored(t, s, p.ID); len(got.Finalized) != 1 || got.Finalized[0].ID != ticket.ID {
+		t.Fatalf("want finalized ticket saved, status %q", b.status)
+	}
+}
@@ -0,0 +1,152 @@
+// Package focus implements keyboard navigation between the tickets of a
+// Project.
+//
+// Stages and tickets form a 2D array, so focus is simply an index for each
+// dimension. Front ends, such as the GUI and the terminal board, share this
+// so that the same keys move focus the same way in each.
+package focus
+
+import (
+	"git.sr.ht/~jackmordaunt/kanban"
+)
+
+// Direction to move focus in.
+type Direction uint8
+
+const (
+	NextTicket Direction = iota
+	PreviousTicket
+	NextStage
+	PreviousStage
+)
+
+// Focus tracks the focused ticket of a project.
+// The zero value focuses nothing, and focuses the first ticket of the first
+// stage on the first move.
+type Focus struct {
+	// Stage and Ticket index the focused ticket within the project, counting
+	// hidden tickets.
+	Stage  int
+	Ticket int
+	// T points at the focused ticket within the project, nil if nothing is
+	// focused.
+	T *kanban.Ticket
+	// Visible reports whether a ticket is shown, such that it can be focused.
+	// Nil means every ticket is shown.
+	Visible func(kanban.Ticket) bool
+}
+
+// Move focus to the ticket in the given direction.
+// Allows movement between tickets and stages in sequential order, wrapping
+// around at either end and skipping hidden tickets and empty stages.
+func (f *Focus) Move(p *kanban.Project, d Direction) {
+	if p == nil || len(p.Stages) == 0 {
+		return
+	}
+	if f.Stage > len(p.Stages)-1 {
+		f.Stage, f.Ticket = 0, 0
+	}
+	if f.T == nil {
+		for _, ii := range f.visible(&p.Stages[f.Stage]) {
+			if ii >= f.Ticket {
+				f.focus(p, ii)
+				return
+			}
+		}
+		return
+	}
+	if len(p.Stages) == 1 && (d == NextStage || d == PreviousStage) {
+		return
+	}
+	// Every stage is visited at most once, which guards against looping
+	// forever when every stage is empty.
+	for range p.Stages {
+		switch d {
+		case NextStage:
+			f.Stage++
+			if f.Stage > len(p.Stages)-1 {
+				f.Stage = 0
+			}
+		case PreviousStage:
+			f.Stage--
+			if f.Stage < 0 {
+				f.Stage = len(p.Stages) - 1
+			}
+		}
+		visible := f.visible(&p.Stages[f.Stage])
+		if len(visible) > 0 {
+			switch d {
+			case NextTicket:
+				// The first ticket below, wrapping around to the top.
+				next := visible[0]
+				for _, ii := range visible {
+					if ii > f.Ticket {
+						next = ii
+						break
+					}
+				}
+				f.focus(p, next)
+			case PreviousTicket:
+				// The first ticket above, wrapping around to the bottom.
+				prev := visible[len(visible)-1]
+				for jj := len(visible) - 1; jj >= 0; jj-- {
+					if visible[jj] < f.Ticket {
+						prev = visible[jj]
+						break
+					}
+				}
+				f.focus(p, prev)
+			default:
+				f.focus(p, visible[0])
+			}
+			return
+		}
+		if d == NextTicket || d == PreviousTicket {
+			// Ticket movement stays within a stage, so an empty stage has
+			// nothing to focus.
+			break
+		}
+	}
+	f.Clear()
+}
+
+// visible returns the indexes of the tickets in the stage that are shown.
+func (f *Focus) visible(s *kanban.Stage) []int {
+	indexes := make([]int, 0, len(s.Tickets))
+	for ii, t := range s.Tickets {
+		if f.Visible == nil || f.Visible(t) {
+			indexes = append(indexes, ii)
+		}
+	}
+	return indexes
+}
+
+// focus the ticket at index ii of the focused stage.
+func (f *Focus) focus(p *kanban.Project, ii int) {
+	f.Ticket = ii
+	f.T = &p.Stages[f.Stage].Tickets[ii]
+}
+
+// To moves focus to the given ticket, wherever it sits.
+// Returns false if the ticket is not on the board.
+func (f *Focus) To(p *kanban.Project, t kanban.Ticket) bool {
+	for ii := range p.Stages {
+		if jj, ok := p.Stages[ii].Index(t); ok {
+			f.Stage = ii
+			f.Ticket = jj
+			f.T = &p.Stages[ii].Tickets[jj]
+			return true
+		}
+	}
+	return false
+}
+
+// Clear focus, so that nothing is focused.
+func (f *Focus) Clear() {
+	f.T = nil
+}
+
+// Reset focus to the start of the board, with nothing focused.
+func (f *Focus) Reset() {
+	f.Stage, f.Ticket, f.T = 0, 0, nil
+}
@@ -0,0 +1,204 @@
+package focus
+
+import (
+	"testing"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"github.com/google/uuid"
+)
+
+// board returns a project with the stages "a", "b" and "c", where "b" is
+// empty. Tickets are titled by their stage and position.
+func board(t *testing.T) *kanban.Project {
+	t.Helper()
+	p := &kanban.Project{}
+	for _, stage := range []struct {
+		name    string
+		tickets []string
+	}{
+		{name: "a", tickets: []string{"a1", "a2", "a3"}},
+		{name: "b"},
+		{name: "c", tickets: []string{"c1", "c2"}},
+	} {
+		id := p.MakeStage(stage.name)
+		for _, title := range stage.tickets {
+			if err := p.AssignTicket(id, kanban.Ticket{ID: uuid.New(), Title: title}); err != nil {
+				t.Fatalf("assigning ticket: %v", err)
+			}
+		}
+	}
+	return p
+}
+
+// focused returns the title of the focused ticket, empty if nothing is
+// focused.
+func focused(f Focus) string {
+	if f.T == nil {
+		return ""
+	}
+	return f.T.Title
+}
+
+// hide returns a Visible func that hides the tickets with the given titles.
+func hide(titles ...string) func(kanban.Ticket) bool {
+	return func(t kanban.Ticket) bool {
+		for _, title := range titles {
+			if t.Title == title {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func TestMove(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		start   Focus
+		visible func(kanban.Ticket) bool
+		moves   []Direction
+		want    string
+	}{
+		{name: "first move focuses the first ticket", moves: []Direction{NextTicket}, want: "a1"},
+		{name: "first move ignores direction", moves: []Direction{PreviousStage}, want: "a1"},
+		{name: "next ticket", moves: []Direction{NextTicket, NextTicket}, want: "a2"},
+		{name: "next ticket wraps", moves: []Direction{NextTicket, NextTicket, NextTicket, NextTicket}, want: "a1"},
+		{name: "previous ticket wraps", moves: []Direction{NextTicket, PreviousTicket}, want: "a3"},
+		{name: "next stage skips empty stages", moves: []Direction{NextTicket, NextStage}, want: "c1"},
+		{name: "next stage wraps", moves: []Direction{NextTicket, NextStage, NextStage}, want: "a1"},
+		{name: "previous stage wraps", moves: []Direction{NextTicket, PreviousStage}, want: "c1"},
+		{name: "stage focuses its first ticket", moves: []Direction{NextTicket, NextTicket, NextStage}, want: "c1"},
+		{
+			name:  "unfocused resumes at its index",
+			start: Focus{Ticket: 1},
+			moves: []Direction{NextTicket},
+			want:  "a2",
+		},
+		{
+			name:  "stage out of range starts over",
+			start: Focus{Stage: 5, Ticket: 2},
+			moves: []Direction{NextTicket},
+			want:  "a1",
+		},
+		{
+			name:    "next ticket skips hidden",
+			visible: hide("a2"),
+			moves:   []Direction{NextTicket, NextTicket},
+			want:    "a3",
+		},
+		{
+			name:    "previous ticket skips hidden",
+			visible: hide("a3"),
+			moves:   []Direction{NextTicket, PreviousTicket},
+			want:    "a2",
+		},
+		{
+			name:    "first move skips hidden",
+			visible: hide("a1"),
+			moves:   []Direction{NextTicket},
+			want:    "a2",
+		},
+		{
+			name:    "stage focuses its first visible ticket",
+			visible: hide("c1"),
+			moves:   []Direction{NextTicket, NextStage},
+			want:    "c2",
+		},
+		{
+			name:    "next stage skips stages with every ticket hidden",
+			visible: hide("c1", "c2"),
+			moves:   []Direction{NextTicket, NextStage},
+			want:    "a1",
+		},
+		{
+			name:    "nothing visible",
+			visible: hide("a1", "a2", "a3", "c1", "c2"),
+			moves:   []Direction{NextTicket, NextStage},
+			want:    "",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := board(t)
+			f := tt.start
+			f.Visible = tt.visible
+			for _, d := range tt.moves {
+				f.Move(p, d)
+			}
+			if got := focused(f); got != tt.want {
+				t.Fatalf("want %q focused, got %q", tt.want, got)
+			}
+			if f.T != nil && f.T != &p.Stages[f.Stage].Tickets[f.Ticket] {
+				t.Fatalf("want T to point at the ticket indexed by Stage and Ticket")
+			}
+		})
+	}
+}
+
+func TestMoveEmpty(t *testing.T) {
+	var f Focus
+	f.Move(nil, NextTicket)
+	f.Move(&kanban.Project{}, NextTicket)
+	if f.T != nil {
+		t.Fatalf("want nothing focused without stages")
+	}
+	p := board(t)
+	p.Stages[0].Tickets = nil
+	p.Stages[2].Tickets = nil
+	f.Move(p, NextTicket)
+	if f.T != nil {
+		t.Fatalf("want nothing focused with every stage empty")
+	}
+}
+
+func TestMoveSingleStage(t *testing.T) {
+	p := board(t)
+	p.Stages = p.Stages[:1]
+	var f Focus
+	f.Move(p, NextTicket)
+	f.Move(p, NextTicket)
+	f.Move(p, NextStage)
+	if got := focused(f); got != "a2" {
+		t.Fatalf("want stage moves ignored with a single stage, got %q focused", got)
+	}
+}
+
+func TestTicketMovesStayInStage(t *testing.T) {
+	p := board(t)
+	var f Focus
+	f.Move(p, NextTicket)
+	f.Stage = 1
+	f.Move(p, NextTicket)
+	if f.T != nil || f.Stage != 1 {
+		t.Fatalf("want nothing focused in an empty stage, got %q in stage %d", focused(f), f.Stage)
+	}
+}
+
+func TestTo(t *testing.T) {
+	p := board(t)
+	var f Focus
+	c2 := p.Stages[2].Tickets[1]
+	if !f.To(p, c2) {
+		t.Fatalf("want ticket on the board focused")
+	}
+	if f.Stage != 2 || f.Ticket != 1 || f.T != &p.Stages[2].Tickets[1] {
+		t.Fatalf("want focus at stage 2 ticket 1, got stage %d ticket %d", f.Stage, f.Ticket)
+	}
+	f.Move(p, PreviousTicket)
+	if got := focused(f); got != "c1" {
+		t.Fatalf("want moves to carry on from the ticket, got %q focused", got)
+	}
+	if f.To(p, kanban.Ticket{ID: uuid.New()}) {
+		t.Fatalf("want missing ticket not focused")
+	}
+	if got := focused(f); got != "c1" {
+		t.Fatalf("want focus unchanged for a missing ticket, got %q", got)
+	}
+	f.Clear()
+	if f.T != nil || f.Stage != 2 || f.Ticket != 0 {
+		t.Fatalf("want clear to unfocus while keeping the position")
+	}
+	f.Reset()
+	if f.T != nil || f.Stage != 0 || f.Ticket != 0 {
+		t.Fatalf("want reset to return to the start")
+	}
+}
@@ -0,0 +1,420 @@
+// Package history implements undo and redo for Project mutations.
+//
+// Each mutation is expressed as a Command that knows how to apply and revert
+// itself against a Project. A History records the commands applied to a
+// Project so that they can be stepped backward and forward.
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"github.com/google/uuid"
+)
+
+// Command is a reversible mutation of a Project.
+type Command interface {
+	// Do applies the mutation.
+	Do(p *kanban.Project) error
+	// Undo reverts the mutation applied by Do.
+	Undo(p *kanban.Project) error
+}
+
+// History records the commands applied to a Project.
+// The zero value is an empty history ready to use.
+type History struct {
+	undo []Command
+	redo []Command
+}
+
+// Do applies the command and records it so that it can be undone.
+// Any commands that were undone can no longer be redone.
+// Commands that fail are not recorded.
+func (h *History) Do(p *kanban.Project, c Command) error {
+	if err := c.Do(p); err != nil {
+		return err
+	}
+	h.undo = append(h.undo, c)
+	h.redo = h.redo[:0]
+	return nil
+}
+
+// Undo reverts the most recent command.
+// Does nothing if there is nothing to undo.
+func (h *History) Undo(p *kanban.Project) error {
+	if len(h.undo) == 0 {
+		return nil
+	}
+	c := h.undo[len(h.undo)-1]
+	if err := c.Undo(p); err != nil {
+		return fmt.Errorf("undo: %w", err)
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, c)
+	return nil
+}
+
+// Redo re-applies the most recently undone command.
+// Does nothing if there is nothing to redo.
+func (h *History) Redo(p *kanban.Project) error {
+	if len(h.redo) == 0 {
+		return nil
+	}
+	c := h.redo[len(h.redo)-1]
+	if err := c.Do(p); err != nil {
+		return fmt.Errorf("redo: %w", err)
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, c)
+	return nil
+}
+
+// position of a ticket within a project.
+type position struct {
+	Stage uuid.UUID
+	Index int
+}
+
+// locate the position of the ticket.
+func locate(p *kanban.Project, t kanban.Ticket) (position, error) {
+	for _, s := range p.Stages {
+		if ii, ok := s.Index(t); ok {
+			return position{Stage: s.ID, Index: ii}, nil
+		}
+	}
+	return position{}, fmt.Errorf("ticket does not exist: %v", t.ID)
+}
+
+// CreateTicket assigns a new ticket to a stage.
+type CreateTicket struct {
+	Stage  uuid.UUID
+	Ticket kanban.Ticket
+}
+
+func (c *CreateTicket) Do(p *kanban.Project) error {
+	// Allocate the ID up front so that undo and redo refer to the same ticket.
+	if c.Ticket.ID == uuid.Nil {
+		c.Ticket.ID = uuid.New()
+		c.Ticket.Created = time.Now()
+	}
+	return p.AssignTicket(c.Stage, c.Ticket)
+}
+
+func (c *CreateTicket) Undo(p *kanban.Project) error {
+	return p.DeleteTicket(c.Ticket)
+}
+
+// EditTicket replaces the contents of a ticket.
+type EditTicket struct {
+	Before kanban.Ticket
+	After  kanban.Ticket
+}
+
+func (c *EditTicket) Do(p *kanban.Project) error {
+	return p.UpdateTicket(c.After)
+}
+
+func (c *EditTicket) Undo(p *kanban.Project) error {
+	return p.UpdateTicket(c.Before)
+}
+
+// MoveTicket relocates a ticket using Move, and puts it back where it was on
+// undo.
+//
+// Any Project method that moves a ticket can be used, for example:
+//
+//	&MoveTicket{Ticket: t, Move: (*kanban.Project).ProgressTicket}
+type MoveTicket struct {
+	Ticket kanban.Ticket
+	Move   func(*kanban.Project, kanban.Ticket) error
+	from   position
+}
+
+func (c *MoveTicket) Do(p *kanban.Project) error {
+	from, err := locate(p, c.Ticket)
+	if err != nil {
+		return err
+	}
+	c.from = from
+	return c.Move(p, c.Ticket)
+}
+
+func (c *MoveTicket) Undo(p *kanban.Project) error {
+	return p.MoveTicketToStage(c.Ticket, c.from.Stage, c.from.Index)
+}
+
+// FinalizeTicket moves a ticket into the finalized archive.
+type FinalizeTicket struct {
+	Ticket kanban.Ticket
+	from   position
+}
+
+func (c *FinalizeTicket) Do(p *kanban.Project) error {
+	from, err := locate(p, c.Ticket)
+	if err != nil {
+		return err
+	}
+	c.from = from
+	return p.FinalizeTicket(c.Ticket)
+}
+
+// Undo puts the ticket back where it was, as it was before it was finalized.
+// The board was already in that state, so the project's rules are not
+// checked again and the ticket's history is not added to, unlike restoring.
+func (c *FinalizeTicket) Undo(p *kanban.Project) error {
+	s := p.Stages.Find(c.from.Stage)
+	if s == nil {
+		return fmt.Errorf("stage does not exist: %v", c.from.Stage)
+	}
+	for ii, t := range p.Finalized {
+		if t.ID != c.Ticket.ID {
+			continue
+		}
+		p.Finalized = append(p.Finalized[:ii], p.Finalized[ii+1:]...)
+		t = t.Clone()
+		t.Finalized = time.Time{}
+		if n := len(t.History); n > 0 && t.History[n-1].Kind == kanban.Finalized {
+			t.History = t.History[:n-1]
+		}
+		s.Insert(t, c.from.Index)
+		return nil
+	}
+	return fmt.Errorf("ticket is not finalized: %v", c.Ticket.ID)
+}
+
+// RestoreTicket takes a finalized ticket out of the archive and into a stage.
+type RestoreTicket struct {
+	Ticket kanban.Ticket
+	Stage  uuid.UUID
+	// archived is the ticket as it was in the archive, and index its place
+	// there, so that undo puts it back exactly as it was.
+	archived kanban.Ticket
+	index    int
+}
+
+func (c *RestoreTicket) Do(p *kanban.Project) error {
+	for ii, t := range p.Finalized {
+		if t.ID == c.Ticket.ID {
+			c.archived = t.Clone()
+			c.index = ii
+			return p.RestoreTicket(c.Ticket, c.Stage)
+		}
+	}
+	return fmt.Errorf("ticket is not finalized: %v", c.Ticket.ID)
+}
+
+func (c *RestoreTicket) Undo(p *kanban.Project) error {
+	if _, ok := p.FindTicket(c.Ticket.ID); !ok {
+		return fmt.Errorf("ticket does not exist: %v", c.Ticket.ID)
+	}
+	if err := p.DeleteTicket(c.Ticket); err != nil {
+		return err
+	}
+	index := c.index
+	if index > len(p.Finalized) {
+		index = len(p.Finalized)
+	}
+	p.Finalized = append(p.Finalized, kanban.Ticket{})
+	copy(p.Finalized[index+1:], p.Finalized[index:])
+	p.Finalized[index] = c.archived.Clone()
+	return nil
+}
+
+// EditProject replaces the settings of a project, such as its name, stages,
+// labels and people, in one step, such as when a project form is submitted.
+// Renaming the project and reordering, adding and removing stages are all
+// recorded this way.
+type EditProject struct {
+	Before Settings
+	After  Settings
+}
+
+func (c *EditProject) Do(p *kanban.Project) error {
+	c.After.apply(p)
+	return nil
+}
+
+func (c *EditProject) Undo(p *kanban.Project) error {
+	c.Before.apply(p)
+	return nil
+}
+
+// Settings are the editable fields of a project.
+//
+// Tickets are recorded by ID only, so that applying settings places tickets
+// in their stages without changing what they say: edits made to a ticket
+// since the settings were recorded are kept.
+type Settings struct {
+	Name             string
+	Limits           kanban.Enforcement
+	Labels           kanban.Labels
+	People           kanban.People
+	StrictChecklists bool
+	Gate             uuid.UUID
+	Blocking         kanban.Enforcement
+	// Stages holds the metadata of each stage, without tickets.
+	Stages kanban.Stages
+	// Placement lists the IDs of the tickets in each stage, in order.
+	Placement [][]uuid.UUID
+	// Tagged maps each label to the tickets carrying it, and Assigned maps
+	// each person to the tickets assigned to them, so that removing a label
+	// or person can be undone.
+	Tagged   map[uuid.UUID][]uuid.UUID
+	Assigned map[uuid.UUID][]uuid.UUID
+}
+
+// SettingsOf records the settings of a project.
+func SettingsOf(p kanban.Project) Settings {
+	p = p.Clone()
+	s := Settings{
+		Name:             p.Name,
+		Limits:           p.Limits,
+		Labels:           p.Labels,
+		People:           p.People,
+		StrictChecklists: p.StrictChecklists,
+		Gate:             p.Gate,
+		Blocking:         p.Blocking,
+		Stages:           make(kanban.Stages, len(p.Stages)),
+		Placement:        make([][]uuid.UUID, len(p.Stages)),
+		Tagged:           make(map[uuid.UUID][]uuid.UUID),
+		Assigned:         make(map[uuid.UUID][]uuid.UUID),
+	}
+	for ii, stage := range p.Stages {
+		for _, t := range stage.Tickets {
+			s.Placement[ii] = append(s.Placement[ii], t.ID)
+		}
+		stage.Tickets = nil
+		s.Stages[ii] = stage
+	}
+	each(&p, func(t *kanban.Ticket) {
+		for _, l := range t.Labels {
+			s.Tagged[l] = append(s.Tagged[l], t.ID)
+		}
+		for _, person := range t.Assignees {
+			s.Assigned[person] = append(s.Assigned[person], t.ID)
+		}
+	})
+	return s
+}
+
+// apply the settings to p.
+//
+// Tickets are moved to the stage they are placed in, recording the move.
+// Tickets that have no place, such as those created since the settings were
+// recorded, stay in their stage, or go to the first stage if their stage no
+// longer exists.
+// Labels and people missing from the settings are removed from tickets, and
+// those the settings add back are restored to the tickets that carried them.
+func (s Settings) apply(p *kanban.Project) {
+	var (
+		tickets = make(map[uuid.UUID]kanban.Ticket)
+		from    = make(map[uuid.UUID]uuid.UUID)
+		stages  = make(kanban.Stages, len(s.Stages))
+	)
+	for _, stage := range p.Stages {
+		for _, t := range stage.Tickets {
+			tickets[t.ID] = t
+			from[t.ID] = stage.ID
+		}
+	}
+	place := func(stage *kanban.Stage, t kanban.Ticket) {
+		if from[t.ID] != stage.ID {
+			t.History = append(t.Clone().History, kanban.Event{
+				Kind: kanban.Moved,
+				At:   time.Now(),
+				From: from[t.ID],
+				To:   stage.ID,
+			})
+		}
+		stage.Tickets = append(stage.Tickets, t)
+		delete(tickets, t.ID)
+	}
+	for ii, stage := range s.Stages {
+		stages[ii] = stage
+		stages[ii].Tickets = []kanban.Ticket{}
+		for _, id := range s.Placement[ii] {
+			// Tickets finalized since are left in the archive.
+			if t, ok := tickets[id]; ok {
+				place(&stages[ii], t)
+			}
+		}
+	}
+	for _, stage := range p.Stages {
+		for _, t := range stage.Tickets {
+			if _, ok := tickets[t.ID]; !ok {
+				continue
+			}
+			if ii, ok := stages.Index(stage.ID); ok {
+				place(&stages[ii], t)
+			} else if len(stages) > 0 {
+				place(&stages[0], t)
+			}
+		}
+	}
+	for ii := range stages {
+		stages[ii].Reorder()
+	}
+	p.Name = s.Name
+	p.Stages = stages
+	p.Limits = s.Limits
+	p.StrictChecklists = s.StrictChecklists
+	p.Gate = s.Gate
+	p.Blocking = s.Blocking
+	// Removing a label or person modifies the catalogue, so iterate over
+	// copies.
+	next := kanban.Project{Labels: s.Labels, People: s.People}
+	for _, l := range append(kanban.Labels(nil), p.Labels...) {
+		if _, ok := next.FindLabel(l.ID); !ok {
+			p.RemoveLabel(l.ID)
+		}
+	}
+	for _, person := range append(kanban.People(nil), p.People...) {
+		if _, ok := next.FindPerson(person.ID); !ok {
+			p.RemovePerson(person.ID)
+		}
+	}
+	for _, l := range s.Labels {
+		if _, ok := p.FindLabel(l.ID); ok {
+			continue
+		}
+		each(p, func(t *kanban.Ticket) {
+			if contains(s.Tagged[l.ID], t.ID) {
+				t.Tag(l.ID)
+			}
+		})
+	}
+	for _, person := range s.People {
+		if _, ok := p.FindPerson(person.ID); ok {
+			continue
+		}
+		each(p, func(t *kanban.Ticket) {
+			if contains(s.Assigned[person.ID], t.ID) {
+				t.Assign(person.ID)
+			}
+		})
+	}
+	p.Labels = append(kanban.Labels(nil), s.Labels...)
+	p.People = append(kanban.People(nil), s.People...)
+}
+
+// each calls fn with every ticket in the project, finalized or not.
+func each(p *kanban.Project, fn func(*kanban.Ticket)) {
+	for ii := range p.Stages {
+		for jj := range p.Stages[ii].Tickets {
+			fn(&p.Stages[ii].Tickets[jj])
+		}
+	}
+	for ii := range p.Finalized {
+		fn(&p.Finalized[ii])
+	}
+}
+
+func contains(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
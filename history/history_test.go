@@ -0,0 +1,269 @@
+package history
+
+import (
+	"image/color"
+	"testing"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"github.com/google/uuid"
+)
+
+// board returns a project with the stages "todo", "doing" and "done", and a
+// single ticket in "todo".
+func board(t *testing.T) (*kanban.Project, kanban.Ticket) {
+	t.Helper()
+	p := &kanban.Project{ID: uuid.New(), Name: "project"}
+	todo := p.MakeStage("todo")
+	p.MakeStage("doing")
+	p.MakeStage("done")
+	ticket := kanban.Ticket{ID: uuid.New(), Title: "ticket"}
+	if err := p.AssignTicket(todo, ticket); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	ticket, _ = p.FindTicket(ticket.ID)
+	return p, ticket
+}
+
+// stageOf returns the name of the stage holding the ticket, empty if none.
+func stageOf(p *kanban.Project, t kanban.Ticket) string {
+	return p.StageForTicket(t).Name
+}
+
+// names lists the names of the project's stages in order.
+func names(p *kanban.Project) []string {
+	var names []string
+	for _, s := range p.Stages {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestCreateTicket(t *testing.T) {
+	p, _ := board(t)
+	var h History
+	c := &CreateTicket{Stage: p.Stages[1].ID, Ticket: kanban.Ticket{Title: "new"}}
+	if err := h.Do(p, c); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	if got := stageOf(p, c.Ticket); got != "doing" {
+		t.Fatalf("want ticket in doing, got %q", got)
+	}
+	if err := h.Undo(p); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if _, ok := p.FindTicket(c.Ticket.ID); ok {
+		t.Fatalf("want ticket removed on undo")
+	}
+	if err := h.Redo(p); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	if got := stageOf(p, c.Ticket); got != "doing" {
+		t.Fatalf("want ticket back in doing on redo, got %q", got)
+	}
+}
+
+func TestEditTicket(t *testing.T) {
+	p, ticket := board(t)
+	var h History
+	after := ticket.Clone()
+	after.Title = "edited"
+	if err := h.Do(p, &EditTicket{Before: ticket, After: after}); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	if got, _ := p.FindTicket(ticket.ID); got.Title != "edited" {
+		t.Fatalf("want edited title, got %q", got.Title)
+	}
+	if err := h.Undo(p); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if got, _ := p.FindTicket(ticket.ID); got.Title != "ticket" {
+		t.Fatalf("want original title on undo, got %q", got.Title)
+	}
+	if err := h.Redo(p); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	if got, _ := p.FindTicket(ticket.ID); got.Title != "edited" {
+		t.Fatalf("want edited title on redo, got %q", got.Title)
+	}
+}
+
+func TestMoveTicket(t *testing.T) {
+	p, ticket := board(t)
+	var h History
+	if err := h.Do(p, &MoveTicket{Ticket: ticket, Move: (*kanban.Project).ProgressTicket}); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	if got := stageOf(p, ticket); got != "doing" {
+		t.Fatalf("want ticket in doing, got %q", got)
+	}
+	if err := h.Undo(p); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if got := stageOf(p, ticket); got != "todo" {
+		t.Fatalf("want ticket back in todo on undo, got %q", got)
+	}
+	if err := h.Redo(p); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	if got := stageOf(p, ticket); got != "doing" {
+		t.Fatalf("want ticket in doing on redo, got %q", got)
+	}
+}
+
+func TestFinalizeTicket(t *testing.T) {
+	p, ticket := board(t)
+	var h History
+	if err := h.Do(p, &FinalizeTicket{Ticket: ticket}); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	if len(p.Finalized) != 1 {
+		t.Fatalf("want 1 finalized ticket, got %d", len(p.Finalized))
+	}
+	if err := h.Undo(p); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if len(p.Finalized) != 0 || stageOf(p, ticket) != "todo" {
+		t.Fatalf("want ticket back in todo on undo, got %q", stageOf(p, ticket))
+	}
+	if err := h.Redo(p); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	if len(p.Finalized) != 1 {
+		t.Fatalf("want 1 finalized ticket on redo, got %d", len(p.Finalized))
+	}
+}
+
+func TestFinalizeTicketUndoFullStage(t *testing.T) {
+	p, ticket := board(t)
+	p.Limits = kanban.Hard
+	p.Stages[0].Limit = 1
+	var h History
+	if err := h.Do(p, &FinalizeTicket{Ticket: ticket}); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	// The stage fills up again before the finalize is undone.
+	if err := p.AssignTicket(p.Stages[0].ID, kanban.Ticket{ID: uuid.New(), Title: "other"}); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	if err := h.Undo(p); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	got, ok := p.FindTicket(ticket.ID)
+	if !ok || stageOf(p, ticket) != "todo" || p.Stages[0].Tickets[0].ID != ticket.ID {
+		t.Fatalf("want ticket back at the top of todo on undo")
+	}
+	if !got.Eq(ticket) {
+		t.Fatalf("want ticket as it was before it was finalized, got %d events", len(got.History))
+	}
+	if len(p.Finalized) != 0 {
+		t.Fatalf("want ticket out of the archive on undo")
+	}
+}
+
+func TestRestoreTicket(t *testing.T) {
+	p, ticket := board(t)
+	if err := p.FinalizeTicket(ticket); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	archived := p.Finalized[0]
+	var h History
+	if err := h.Do(p, &RestoreTicket{Ticket: ticket, Stage: p.Stages[2].ID}); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	if got := stageOf(p, ticket); got != "done" || len(p.Finalized) != 0 {
+		t.Fatalf("want ticket restored into done, got %q", got)
+	}
+	if err := h.Undo(p); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if _, ok := p.FindTicket(ticket.ID); ok {
+		t.Fatalf("want ticket out of the stages on undo")
+	}
+	if len(p.Finalized) != 1 || !p.Finalized[0].Eq(archived) {
+		t.Fatalf("want ticket back in the archive as it was on undo")
+	}
+}
+
+func TestEditProject(t *testing.T) {
+	p, ticket := board(t)
+	bug := p.MakeLabel("bug", color.NRGBA{A: 255})
+	tagged := ticket.Clone()
+	tagged.Tag(bug)
+	if err := p.UpdateTicket(tagged); err != nil {
+		t.Fatalf("tagging ticket: %v", err)
+	}
+	before := p.Clone()
+	// Rename the project, swap the last two stages, remove the first stage
+	// moving its ticket into "done", and remove the label, as the project
+	// form would.
+	after := p.Clone()
+	after.Name = "renamed"
+	after.MoveStage(after.Stages[1].ID, kanban.Forward)
+	if err := after.RemoveStage(after.Stages[0].ID, after.Stages[1].ID); err != nil {
+		t.Fatalf("removing stage: %v", err)
+	}
+	after.RemoveLabel(bug)
+	var h History
+	if err := h.Do(p, &EditProject{Before: SettingsOf(before), After: SettingsOf(after)}); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	if p.Name != "renamed" || len(p.Stages) != 2 || p.Stages[0].Name != "done" {
+		t.Fatalf("want renamed project with stages done, doing, got %q %v", p.Name, names(p))
+	}
+	if got := stageOf(p, ticket); got != "done" {
+		t.Fatalf("want ticket moved into done, got %q", got)
+	}
+	if got, _ := p.FindTicket(ticket.ID); got.Tagged(bug) || len(p.Labels) != 0 {
+		t.Fatalf("want label removed from the ticket and catalogue")
+	}
+	// An edit made after the project edit is kept when it is undone.
+	edited, _ := p.FindTicket(ticket.ID)
+	edited.Title = "edited"
+	if err := p.UpdateTicket(edited); err != nil {
+		t.Fatalf("updating ticket: %v", err)
+	}
+	if err := h.Undo(p); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if p.Name != "project" || len(p.Stages) != 3 || p.Stages[0].Name != "todo" {
+		t.Fatalf("want original project on undo, got %q %v", p.Name, names(p))
+	}
+	got, _ := p.FindTicket(ticket.ID)
+	if stageOf(p, ticket) != "todo" || !got.Tagged(bug) {
+		t.Fatalf("want ticket back in todo and tagged on undo")
+	}
+	if got.Title != "edited" {
+		t.Fatalf("want ticket edit kept on undo, got %q", got.Title)
+	}
+	if err := h.Redo(p); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	if p.Name != "renamed" || stageOf(p, ticket) != "done" {
+		t.Fatalf("want project edit reapplied on redo")
+	}
+}
+
+func TestEditProjectKeepsFinalized(t *testing.T) {
+	p, ticket := board(t)
+	if err := p.FinalizeTicket(ticket); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	var h History
+	after := p.Clone()
+	after.Name = "renamed"
+	if err := h.Do(p, &EditProject{Before: SettingsOf(*p), After: SettingsOf(after)}); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	if err := h.Do(p, &RestoreTicket{Ticket: ticket, Stage: p.Stages[0].ID}); err != nil {
+		t.Fatalf("restoring: %v", err)
+	}
+	if err := h.Undo(p); err != nil {
+		t.Fatalf("undoing restore: %v", err)
+	}
+	if err := h.Undo(p); err != nil {
+		t.Fatalf("undoing project edit: %v", err)
+	}
+	if len(p.Finalized) != 1 {
+		t.Fatalf("want ticket kept in the archive, got %d finalized", len(p.Finalized))
+	}
+}
@@ -8,10 +8,18 @@ import (
 var (
 	BackIcon      *widget.Icon = must(widget.NewIcon(icons.NavigationArrowBack))
 	ForwardIcon   *widget.Icon = must(widget.NewIcon(icons.NavigationArrowForward))
+	UpIcon        *widget.Icon = must(widget.NewIcon(icons.NavigationArrowUpward))
+	DownIcon      *widget.Icon = must(widget.NewIcon(icons.NavigationArrowDownward))
 	ContentEdit   *widget.Icon = must(widget.NewIcon(icons.ContentCreate))
 	ContentDelete *widget.Icon = must(widget.NewIcon(icons.ContentDeleteSweep))
 	ContentAdd    *widget.Icon = must(widget.NewIcon(icons.ContentAdd))
 	Configuration *widget.Icon = must(widget.NewIcon(icons.ActionSettings))
+	Archive       *widget.Icon = must(widget.NewIcon(icons.ContentArchive))
+	Schedule      *widget.Icon = must(widget.NewIcon(icons.ActionSchedule))
+	Person        *widget.Icon = must(widget.NewIcon(icons.SocialPerson))
+	Send          *widget.Icon = must(widget.NewIcon(icons.ContentSend))
+	Chart         *widget.Icon = must(widget.NewIcon(icons.EditorShowChart))
+	Flow          *widget.Icon = must(widget.NewIcon(icons.EditorInsertChart))
 )
 
 func must(icon *widget.Icon, err error) *widget.Icon {
@@ -4,6 +4,7 @@
 // of Stages and Tickets.
 //
 // Projects are independent of each other.
+// Each project keeps a directory of the people working on it.
 //
 // Notes:
 //
@@ -17,11 +18,15 @@
 //
 // Stage
 // - represents an important part in the lifecycle of a task, described by a name
+// - is identified by a unique ID, so that names are free to change and repeat
 // - contains an ordered list of tickets
 // - tickets are re-orderable
 // - tickets can advance back and forth between stages, typically linearly
 // - can be renamed
 // - can be deleted
+// - can limit the number of tickets it holds (work-in-progress limit)
+// - can keep its tickets sorted by priority, otherwise tickets are ordered manually
+// - can flag tickets that have sat in it too long as stale
 //
 // Ticket
 // - contains information about a task for a project
@@ -29,11 +34,24 @@
 // - cannot occupy more than one stage
 // - can be edited
 // - can be deleted
+// - can be finalized into an archive, and restored from it
+// - can be tagged with any number of labels from the project's catalogue
+// - has a priority: critical, high, normal or low
+// - can be due on a given date
+// - can be assigned to any number of people from the project's directory
+// - can break down into an ordered checklist of items, each done or not
+// - can be blocked by other tickets in the project, until they are finalized
+// - carries a thread of comments, each editable only by its author
+// - keeps an append-only history of what happened to it, and when
 package kanban
 
 import (
 	"fmt"
+	"image/color"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 )
@@ -47,73 +65,680 @@ type Project struct {
 	Stages Stages
 	// Finalized is a psuedo stage that contains all finalized tickets.
 	Finalized []Ticket
+	// Limits selects how stage work-in-progress limits are enforced.
+	Limits Enforcement
+	// Labels is the catalogue of labels tickets can be tagged with.
+	Labels Labels
+	// People is the directory of people tickets can be assigned to.
+	People People
+	// StrictChecklists refuses to finalize tickets with open checklist items.
+	StrictChecklists bool
+	// Gate is the stage that blocked tickets should not move past.
+	// Nil means tickets move freely regardless of their blockers.
+	Gate uuid.UUID
+	// Blocking selects how the gate is enforced: soft enforcement lets
+	// blocked tickets through, leaving the caller to warn about it.
+	Blocking Enforcement
+	// Revision counts the saves of the project, so that storage can detect a
+	// save based on an out of date copy.
+	// Maintained by storage, not by the methods of Project.
+	Revision uint64
 }
 
-// MakeStage assigns a ticket to the given stage.
-func (p *Project) MakeStage(name string) {
+// Person is a member of a project that can be assigned tickets.
+type Person struct {
+	ID uuid.UUID
+	// Name of the person.
+	Name string
+}
+
+// Initials abbreviates the person's name, such as "JD" for "Jane Doe".
+func (p Person) Initials() string {
+	var initials []rune
+	for _, word := range strings.Fields(p.Name) {
+		for _, r := range word {
+			initials = append(initials, unicode.ToUpper(r))
+			break
+		}
+		if len(initials) == 2 {
+			break
+		}
+	}
+	return string(initials)
+}
+
+// Label categorises tickets, such as "bug" or "feature".
+type Label struct {
+	ID uuid.UUID
+	// Name of the label.
+	Name string
+	// Color the label is rendered with.
+	Color color.NRGBA
+}
+
+// Enforcement selects how stage work-in-progress limits are enforced.
+type Enforcement int8
+
+const (
+	// Soft enforcement allows a stage to exceed its limit, in which case the
+	// stage is flagged as exceeded.
+	Soft Enforcement = iota
+	// Hard enforcement refuses to move tickets into a stage at its limit.
+	Hard
+)
+
+// LimitError is returned when a ticket cannot be moved into a stage because
+// the stage is at its work-in-progress limit.
+type LimitError struct {
+	Stage string
+	Limit int
+}
+
+func (err LimitError) Error() string {
+	return fmt.Sprintf("stage %q is at its limit of %d tickets", err.Stage, err.Limit)
+}
+
+// BlockedError is returned when a ticket cannot move past the gate stage
+// because it is blocked by tickets that are not yet finalized.
+type BlockedError struct {
+	Ticket   string
+	Blockers []string
+}
+
+func (err BlockedError) Error() string {
+	return fmt.Sprintf("ticket %q is blocked by %s", err.Ticket, strings.Join(quote(err.Blockers), ", "))
+}
+
+// CycleError is returned when linking tickets would make a ticket depend on
+// itself.
+type CycleError struct {
+	// Path lists the titles of the tickets forming the cycle, starting and
+	// ending with the same ticket.
+	Path []string
+}
+
+func (err CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle: %s", strings.Join(quote(err.Path), " -> "))
+}
+
+func quote(titles []string) []string {
+	quoted := make([]string, len(titles))
+	for ii, title := range titles {
+		quoted[ii] = fmt.Sprintf("%q", title)
+	}
+	return quoted
+}
+
+// ChecklistError is returned when a ticket cannot be finalized because its
+// checklist has open items.
+type ChecklistError struct {
+	Ticket string
+	Open   int
+}
+
+func (err ChecklistError) Error() string {
+	return fmt.Sprintf("ticket %q has %d open checklist items", err.Ticket, err.Open)
+}
+
+// MakeStage appends a new stage with a unique ID.
+func (p *Project) MakeStage(name string) uuid.UUID {
+	id := uuid.New()
 	p.Stages = append(p.Stages, Stage{
+		ID:   id,
 		Name: name,
 	})
+	return id
 }
 
 func (p *Project) ListStages() []Stage {
 	return p.Stages
 }
 
-func (p *Project) MoveStage(name string, dir Direction) bool {
-	return p.Stages.Swap(name, dir)
+func (p *Project) MoveStage(stage uuid.UUID, dir Direction) bool {
+	return p.Stages.Swap(stage, dir)
+}
+
+// RemoveStage deletes a stage, moving any tickets it holds to the end of the
+// stage identified by into.
+// It is an error to remove a stage that does not exist, or to remove a stage
+// that holds tickets without a different stage to move them into.
+// Returns a LimitError, removing nothing, if limits are hard and the tickets
+// would take the other stage over its limit.
+func (p *Project) RemoveStage(stage, into uuid.UUID) error {
+	ii, ok := p.Stages.Index(stage)
+	if !ok {
+		return fmt.Errorf("stage does not exist: %v", stage)
+	}
+	if tickets := p.Stages[ii].Tickets; len(tickets) > 0 {
+		jj, ok := p.Stages.Index(into)
+		if !ok || jj == ii {
+			return fmt.Errorf("no stage to move tickets into: %v", into)
+		}
+		if dst := p.Stages[jj]; p.Limits == Hard && dst.Limit > 0 && len(dst.Tickets)+len(tickets) > dst.Limit {
+			return LimitError{Stage: dst.Name, Limit: dst.Limit}
+		}
+		for _, t := range tickets {
+			t.record(Event{Kind: Moved, From: stage, To: into})
+			p.Stages[jj].Tickets = append(p.Stages[jj].Tickets, t)
+		}
+	}
+	p.Stages = append(p.Stages[:ii], p.Stages[ii+1:]...)
+	return nil
+}
+
+// MakeLabel appends a new label to the catalogue with a unique ID.
+func (p *Project) MakeLabel(name string, c color.NRGBA) uuid.UUID {
+	id := uuid.New()
+	p.Labels = append(p.Labels, Label{
+		ID:    id,
+		Name:  name,
+		Color: c,
+	})
+	return id
+}
+
+// FindLabel returns the label in the catalogue with the given ID.
+func (p *Project) FindLabel(id uuid.UUID) (Label, bool) {
+	for _, l := range p.Labels {
+		if l.ID == id {
+			return l, true
+		}
+	}
+	return Label{}, false
+}
+
+// RemoveLabel deletes a label from the catalogue and untags every ticket,
+// finalized or not, that carries it.
+func (p *Project) RemoveLabel(id uuid.UUID) {
+	for ii, l := range p.Labels {
+		if l.ID == id {
+			p.Labels = append(p.Labels[:ii], p.Labels[ii+1:]...)
+			break
+		}
+	}
+	for ii := range p.Stages {
+		for jj := range p.Stages[ii].Tickets {
+			p.Stages[ii].Tickets[jj].Untag(id)
+		}
+	}
+	for ii := range p.Finalized {
+		p.Finalized[ii].Untag(id)
+	}
+}
+
+// LabelsFor resolves the labels a ticket is tagged with, in catalogue order.
+// Tags that no longer exist in the catalogue are ignored.
+func (p *Project) LabelsFor(t Ticket) []Label {
+	var labels []Label
+	for _, l := range p.Labels {
+		if t.Tagged(l.ID) {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+// TicketsByDue returns the tickets across all stages ordered by due date,
+// soonest first, followed by the tickets without a due date in board order.
+// Finalized tickets are not included.
+func (p *Project) TicketsByDue() []Ticket {
+	var tickets []Ticket
+	for _, s := range p.Stages {
+		tickets = append(tickets, s.Tickets...)
+	}
+	sort.SliceStable(tickets, func(ii, jj int) bool {
+		a, b := tickets[ii].Due, tickets[jj].Due
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	})
+	return tickets
+}
+
+// MakePerson appends a new person to the directory with a unique ID.
+func (p *Project) MakePerson(name string) uuid.UUID {
+	id := uuid.New()
+	p.People = append(p.People, Person{
+		ID:   id,
+		Name: name,
+	})
+	return id
+}
+
+// FindPerson returns the person in the directory with the given ID.
+func (p *Project) FindPerson(id uuid.UUID) (Person, bool) {
+	for _, person := range p.People {
+		if person.ID == id {
+			return person, true
+		}
+	}
+	return Person{}, false
+}
+
+// FindPersonByName returns the person in the directory with the given name,
+// ignoring case.
+func (p *Project) FindPersonByName(name string) (Person, bool) {
+	for _, person := range p.People {
+		if strings.EqualFold(person.Name, name) {
+			return person, true
+		}
+	}
+	return Person{}, false
+}
+
+// RemovePerson deletes a person from the directory and unassigns them from
+// every ticket, finalized or not.
+func (p *Project) RemovePerson(id uuid.UUID) {
+	for ii, person := range p.People {
+		if person.ID == id {
+			p.People = append(p.People[:ii], p.People[ii+1:]...)
+			break
+		}
+	}
+	for ii := range p.Stages {
+		for jj := range p.Stages[ii].Tickets {
+			p.Stages[ii].Tickets[jj].Unassign(id)
+		}
+	}
+	for ii := range p.Finalized {
+		p.Finalized[ii].Unassign(id)
+	}
+}
+
+// AssigneesFor resolves the people a ticket is assigned to, in directory
+// order.
+// Assignees that no longer exist in the directory are ignored.
+func (p *Project) AssigneesFor(t Ticket) []Person {
+	var people []Person
+	for _, person := range p.People {
+		if t.AssignedTo(person.ID) {
+			people = append(people, person)
+		}
+	}
+	return people
+}
+
+// CanBlock validates that ticket can be blocked by blocker.
+// It is an error to link a blocker that does not exist, and a CycleError if
+// the blocker already depends on the ticket.
+func (p *Project) CanBlock(ticket, blocker uuid.UUID) error {
+	b, ok := p.lookup(blocker)
+	if !ok {
+		return fmt.Errorf("ticket does not exist: %v", blocker)
+	}
+	if ticket == blocker {
+		return CycleError{Path: []string{b.Title, b.Title}}
+	}
+	if path := p.path(blocker, ticket, map[uuid.UUID]bool{}); path != nil {
+		t, _ := p.lookup(ticket)
+		titles := []string{t.Title}
+		for _, id := range path {
+			dep, _ := p.lookup(id)
+			titles = append(titles, dep.Title)
+		}
+		return CycleError{Path: titles}
+	}
+	return nil
+}
+
+// path returns the chain of dependencies leading from one ticket to another,
+// inclusive, or nil if there is none.
+func (p *Project) path(from, to uuid.UUID, seen map[uuid.UUID]bool) []uuid.UUID {
+	if from == to {
+		return []uuid.UUID{to}
+	}
+	if seen[from] {
+		return nil
+	}
+	seen[from] = true
+	t, _ := p.lookup(from)
+	for _, next := range t.BlockedBy {
+		if rest := p.path(next, to, seen); rest != nil {
+			return append([]uuid.UUID{from}, rest...)
+		}
+	}
+	return nil
+}
+
+// Block the ticket on another ticket, if not already blocked by it.
+// The ticket may be one not yet assigned to the project, such as a draft.
+// It is an error to link a blocker that does not exist, and a CycleError if
+// the blocker already depends on the ticket.
+func (p *Project) Block(t *Ticket, blocker uuid.UUID) error {
+	if t.DependsOn(blocker) {
+		return nil
+	}
+	if err := p.CanBlock(t.ID, blocker); err != nil {
+		return err
+	}
+	t.BlockedBy = append(t.Clone().BlockedBy, blocker)
+	return nil
+}
+
+// link validates the blockers that ticket has and old did not, see CanBlock.
+// Blockers already linked are kept as they are, even if they have since been
+// deleted.
+func (p *Project) link(ticket, old Ticket) error {
+	for _, blocker := range ticket.BlockedBy {
+		if old.DependsOn(blocker) {
+			continue
+		}
+		if err := p.CanBlock(ticket.ID, blocker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Blocks returns the tickets on the board that the given ticket blocks.
+func (p *Project) Blocks(ticket Ticket) []Ticket {
+	var blocked []Ticket
+	for _, s := range p.Stages {
+		for _, t := range s.Tickets {
+			if t.DependsOn(ticket.ID) {
+				blocked = append(blocked, t)
+			}
+		}
+	}
+	return blocked
+}
+
+// Blockers returns the tickets blocking the given ticket that are not yet
+// finalized.
+// Blockers that have since been deleted are ignored.
+func (p *Project) Blockers(ticket Ticket) []Ticket {
+	var blockers []Ticket
+	for _, id := range ticket.BlockedBy {
+		if t, ok := p.FindTicket(id); ok {
+			blockers = append(blockers, t)
+		}
+	}
+	return blockers
+}
+
+// PastGate reports whether the ticket sits in a stage after the gate.
+func (p *Project) PastGate(ticket Ticket) bool {
+	gate, ok := p.Stages.Index(p.Gate)
+	if !ok {
+		return false
+	}
+	for ii := gate + 1; ii < len(p.Stages); ii++ {
+		if p.Stages[ii].Contains(ticket) {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocked reports whether the ticket has blockers that are not yet finalized.
+func (p *Project) Blocked(ticket Ticket) bool {
+	return len(p.Blockers(ticket)) > 0
+}
+
+// clear returns a BlockedError if a ticket moving from the stage at index src
+// to the stage at index dst would pass the gate while blocked, and blocking
+// is hard.
+func (p *Project) clear(ticket Ticket, src, dst int) error {
+	gate, ok := p.Stages.Index(p.Gate)
+	if !ok || p.Blocking != Hard || dst <= gate || src > gate {
+		return nil
+	}
+	blockers := p.Blockers(ticket)
+	if len(blockers) == 0 {
+		return nil
+	}
+	err := BlockedError{Ticket: ticket.Title}
+	for _, b := range blockers {
+		err.Blockers = append(err.Blockers, b.Title)
+	}
+	return err
+}
+
+// lookup finds a ticket by ID whether it sits in a stage or has been
+// finalized.
+func (p *Project) lookup(id uuid.UUID) (Ticket, bool) {
+	if t, ok := p.FindTicket(id); ok {
+		return t, true
+	}
+	for _, t := range p.Finalized {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Ticket{}, false
 }
 
 // AssignTicket assigns a ticket to the given stage.
-func (p *Project) AssignTicket(stage string, ticket Ticket) error {
-	return p.Stages.Find(stage).Assign(ticket)
+// It is an error to assign a ticket to a stage that does not exist, or with a
+// blocker that does not exist.
+func (p *Project) AssignTicket(stage uuid.UUID, ticket Ticket) error {
+	ii, ok := p.Stages.Index(stage)
+	if !ok {
+		return fmt.Errorf("stage does not exist: %v", stage)
+	}
+	if err := p.admit(&p.Stages[ii]); err != nil {
+		return err
+	}
+	if err := p.link(ticket, Ticket{}); err != nil {
+		return err
+	}
+	return p.Stages[ii].add(ticket)
+}
+
+// admit returns a LimitError if the stage cannot accept another ticket under
+// the project's enforcement mode.
+func (p *Project) admit(s *Stage) error {
+	if p.Limits == Hard && s.Full() {
+		return LimitError{Stage: s.Name, Limit: s.Limit}
+	}
+	return nil
 }
 
 // Update an existing ticket.
-// It is an error to attempt to update a ticket that does not exist.
+// It is an error to attempt to update a ticket that does not exist, or to add
+// a blocker that does not exist, and a CycleError to add a blocker that
+// depends on the ticket.
+// The ticket's history is carried over from the existing ticket, recording
+// the fields that changed.
 func (p *Project) UpdateTicket(ticket Ticket) error {
-	for _, s := range p.Stages {
-		if s.Update(ticket) {
+	old, ok := p.FindTicket(ticket.ID)
+	if !ok {
+		return fmt.Errorf("ticket does not exist: %v", ticket)
+	}
+	if err := p.link(ticket, old); err != nil {
+		return err
+	}
+	ticket.History = old.History
+	if changes := p.diff(old, ticket); len(changes) > 0 {
+		ticket.record(Event{Kind: Edited, Changes: changes})
+	}
+	for ii := range p.Stages {
+		if p.Stages[ii].Update(ticket) {
 			return nil
 		}
 	}
 	return fmt.Errorf("ticket does not exist: %v", ticket)
 }
 
-// ProgressTicket moves a ticket to the "next" stage.
-func (p *Project) ProgressTicket(ticket Ticket) {
-	for ii, s := range p.Stages {
-		if s.Contains(ticket) {
-			if ii < len(p.Stages)-1 {
-				_ = p.Stages[ii+1].Assign(p.Stages[ii].Take(ticket))
+// diff lists the changes between two versions of a ticket, by field.
+// Comments and history are not considered part of the ticket's fields.
+func (p *Project) diff(before, after Ticket) []Change {
+	var changes []Change
+	field := func(name, before, after string) {
+		if before != after {
+			changes = append(changes, Change{Field: name, Before: before, After: after})
+		}
+	}
+	field("title", before.Title, after.Title)
+	field("summary", before.Summary, after.Summary)
+	field("details", before.Details, after.Details)
+	field("priority", before.Priority.String(), after.Priority.String())
+	field("due", date(before.Due), date(after.Due))
+	labels := func(t Ticket) string {
+		var names []string
+		for _, l := range p.LabelsFor(t) {
+			names = append(names, l.Name)
+		}
+		return strings.Join(names, ", ")
+	}
+	field("labels", labels(before), labels(after))
+	assignees := func(t Ticket) string {
+		var names []string
+		for _, person := range p.AssigneesFor(t) {
+			names = append(names, person.Name)
+		}
+		return strings.Join(names, ", ")
+	}
+	field("assignees", assignees(before), assignees(after))
+	blockers := func(t Ticket) string {
+		var titles []string
+		for _, id := range t.BlockedBy {
+			if b, ok := p.lookup(id); ok {
+				titles = append(titles, b.Title)
 			}
-			break
 		}
+		return strings.Join(titles, ", ")
 	}
+	field("blocked by", blockers(before), blockers(after))
+	checklist := func(t Ticket) string {
+		var items []string
+		for _, item := range t.Checklist {
+			mark := "[ ]"
+			if item.Done {
+				mark = "[x]"
+			}
+			items = append(items, mark+" "+item.Text)
+		}
+		return strings.Join(items, ", ")
+	}
+	field("checklist", checklist(before), checklist(after))
+	return changes
+}
+
+// date formats a day, where the zero time is formatted as empty.
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// ProgressTicket moves a ticket to the "next" stage.
+// Returns a LimitError if the next stage is at its limit and limits are hard,
+// or a BlockedError if the ticket is blocked from passing the gate and
+// blocking is hard.
+func (p *Project) ProgressTicket(ticket Ticket) error {
+	return p.step(ticket, Forward)
 }
 
 // RegressTicket moves a ticket to the "previous" stage.
-func (p *Project) RegressTicket(ticket Ticket) {
+// Returns a LimitError if the previous stage is at its limit and limits are
+// hard.
+func (p *Project) RegressTicket(ticket Ticket) error {
+	return p.step(ticket, Backward)
+}
+
+// step moves a ticket to the adjacent stage in the given direction.
+// Tickets at either end of the pipeline stay put.
+func (p *Project) step(ticket Ticket, dir Direction) error {
 	for ii, s := range p.Stages {
 		if s.Contains(ticket) {
-			if ii > 0 {
-				_ = p.Stages[ii-1].Assign(p.Stages[ii].Take(ticket))
+			if jj := ii + dir.Next(); jj >= 0 && jj < len(p.Stages) {
+				if err := p.admit(&p.Stages[jj]); err != nil {
+					return err
+				}
+				kk, _ := s.Index(ticket)
+				if err := p.clear(s.Tickets[kk], ii, jj); err != nil {
+					return err
+				}
+				dst := &p.Stages[jj]
+				t := p.Stages[ii].Take(ticket)
+				t.record(Event{Kind: Moved, From: s.ID, To: dst.ID})
+				dst.Insert(t, dst.slot(t))
 			}
 			break
 		}
 	}
+	return nil
 }
 
 // MoveTicket within a stage.
+// Returns false when at a boundary, and therefore no move can occur.
 func (p *Project) MoveTicket(ticket Ticket, dir Direction) bool {
-	// @implement
-	return false
+	return p.StageForTicket(ticket).Swap(ticket, dir)
+}
+
+// MoveTicketTo moves a ticket to the given position within its stage.
+// Returns false when the position is out of bounds, or the ticket is already
+// there.
+func (p *Project) MoveTicketTo(ticket Ticket, index int) bool {
+	return p.StageForTicket(ticket).Move(ticket, index)
 }
 
-func (p *Project) ListTickets(stage string) []Ticket {
+// MoveTicketToTop moves a ticket to the top of its stage.
+func (p *Project) MoveTicketToTop(ticket Ticket) bool {
+	return p.MoveTicketTo(ticket, 0)
+}
+
+// MoveTicketToBottom moves a ticket to the bottom of its stage.
+func (p *Project) MoveTicketToBottom(ticket Ticket) bool {
+	s := p.StageForTicket(ticket)
+	return s.Move(ticket, len(s.Tickets)-1)
+}
+
+// MoveTicketToStage moves a ticket into the given stage at the given position
+// in one step. The position is clamped to the bounds of the stage, and
+// ignored for a stage sorted by priority, which places the ticket by rank.
+// It is an error to move a ticket that does not exist, or to move a ticket
+// into a stage that does not exist.
+func (p *Project) MoveTicketToStage(ticket Ticket, stage uuid.UUID, index int) error {
+	dst, ok := p.Stages.Index(stage)
+	if !ok {
+		return fmt.Errorf("stage does not exist: %v", stage)
+	}
+	src := p.StageForTicket(ticket)
+	ii, ok := src.Index(ticket)
+	if !ok {
+		return fmt.Errorf("ticket does not exist: %v", ticket)
+	}
+	if src != &p.Stages[dst] {
+		if err := p.admit(&p.Stages[dst]); err != nil {
+			return err
+		}
+		from, _ := p.Stages.Index(src.ID)
+		if err := p.clear(src.Tickets[ii], from, dst); err != nil {
+			return err
+		}
+	}
+	t := src.Take(src.Tickets[ii])
+	if src.ID != p.Stages[dst].ID {
+		t.record(Event{Kind: Moved, From: src.ID, To: p.Stages[dst].ID})
+	}
+	if p.Stages[dst].Sort == ByPriority {
+		index = p.Stages[dst].slot(t)
+	}
+	p.Stages[dst].Insert(t, index)
+	return nil
+}
+
+func (p *Project) ListTickets(stage uuid.UUID) []Ticket {
 	return p.Stages.Find(stage).Tickets
 }
 
+// FindTicket returns the ticket with the given ID from any stage.
+// Finalized tickets are not searched.
+func (p *Project) FindTicket(id uuid.UUID) (Ticket, bool) {
+	for _, s := range p.Stages {
+		for _, t := range s.Tickets {
+			if t.ID == id {
+				return t, true
+			}
+		}
+	}
+	return Ticket{}, false
+}
+
 // StageForTicket returns the stage containing the specified ticket.
 func (p *Project) StageForTicket(ticket Ticket) *Stage {
 	for ii, s := range p.Stages {
@@ -125,25 +750,158 @@ func (p *Project) StageForTicket(ticket Ticket) *Stage {
 }
 
 // FinalizeTicket renders the ticket "complete" and moves it into an archive.
-func (p *Project) FinalizeTicket(t Ticket) {
+// It is an error to finalize a ticket that is not in a stage.
+// Returns a ChecklistError if checklists are strict and the ticket has open
+// items.
+func (p *Project) FinalizeTicket(ticket Ticket) error {
 	for ii, s := range p.Stages {
-		if s.Contains(t) {
+		if jj, ok := s.Index(ticket); ok {
+			// Check the ticket as stored, since the caller's copy may be out
+			// of date.
+			t := s.Tickets[jj]
+			if done, total := t.Progress(); p.StrictChecklists && done < total {
+				return ChecklistError{Ticket: t.Title, Open: total - done}
+			}
 			p.Stages[ii].UnAssign(t)
+			t.Finalized = time.Now()
+			t.record(Event{Kind: Finalized, From: s.ID, At: t.Finalized})
 			p.Finalized = append(p.Finalized, t)
-			break
+			return nil
+		}
+	}
+	return fmt.Errorf("ticket does not exist: %v", ticket.ID)
+}
+
+// DeleteTicket permanently removes a ticket, whether it sits in a stage or has
+// been finalized.
+// It is an error to delete a ticket that does not exist.
+func (p *Project) DeleteTicket(ticket Ticket) error {
+	for ii := range p.Stages {
+		if jj, ok := p.Stages[ii].Index(ticket); ok {
+			p.Stages[ii].UnAssign(p.Stages[ii].Tickets[jj])
+			return nil
+		}
+	}
+	for ii, t := range p.Finalized {
+		if t.ID == ticket.ID {
+			p.Finalized = append(p.Finalized[:ii], p.Finalized[ii+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("ticket does not exist: %v", ticket)
+}
+
+// RestoreTicket takes a finalized ticket out of the archive and appends it to
+// the given stage.
+// It is an error to restore a ticket that is not finalized, or to restore into
+// a stage that does not exist.
+// Returns a LimitError if the stage is at its limit and limits are hard, or a
+// BlockedError if the stage is past the gate, the ticket is blocked and
+// blocking is hard.
+func (p *Project) RestoreTicket(ticket Ticket, stage uuid.UUID) error {
+	dst, ok := p.Stages.Index(stage)
+	if !ok {
+		return fmt.Errorf("stage does not exist: %v", stage)
+	}
+	for ii, t := range p.Finalized {
+		if t.ID == ticket.ID {
+			if err := p.admit(&p.Stages[dst]); err != nil {
+				return err
+			}
+			// A restored ticket enters the board afresh, as if from before
+			// the first stage.
+			if err := p.clear(t, -1, dst); err != nil {
+				return err
+			}
+			p.Finalized = append(p.Finalized[:ii], p.Finalized[ii+1:]...)
+			t.Finalized = time.Time{}
+			t.record(Event{Kind: Restored, To: stage})
+			p.Stages[dst].Insert(t, p.Stages[dst].slot(t))
+			return nil
 		}
 	}
+	return fmt.Errorf("ticket is not finalized: %v", ticket)
 }
 
 // Stage in the kanban pipeline, can hold a number of tickets.
 type Stage struct {
+	ID      uuid.UUID
 	Name    string
 	Tickets []Ticket
+	// Limit is the maximum number of tickets the stage should hold.
+	// Zero means no limit.
+	Limit int
+	// Sort selects how tickets are ordered as they enter the stage.
+	Sort SortPolicy
+	// StaleAfter is how long a ticket can sit in the stage before it is
+	// considered stale.
+	// Zero means tickets never go stale.
+	StaleAfter time.Duration
 }
 
-// Assign appends a ticket to the stage with a unique ID.
+// SortPolicy selects how a stage orders its tickets.
+type SortPolicy int8
+
+const (
+	// Manual keeps tickets in the order they are placed, appending new
+	// tickets to the end.
+	Manual SortPolicy = iota
+	// ByPriority keeps tickets ordered by priority, highest first, then by
+	// age, oldest first.
+	ByPriority
+)
+
+// slot returns the index a ticket entering the stage should be inserted at.
+func (s *Stage) slot(ticket Ticket) int {
+	if s.Sort == ByPriority {
+		for ii, t := range s.Tickets {
+			if ticket.Outranks(t) {
+				return ii
+			}
+		}
+	}
+	return len(s.Tickets)
+}
+
+// Reorder sorts the tickets according to the stage's sort policy.
+// Manually ordered stages are left as is.
+func (s *Stage) Reorder() {
+	if s.Sort == ByPriority {
+		sort.SliceStable(s.Tickets, func(ii, jj int) bool {
+			return s.Tickets[ii].Outranks(s.Tickets[jj])
+		})
+	}
+}
+
+// Full reports whether the stage has reached its limit.
+func (s *Stage) Full() bool {
+	return s.Limit > 0 && len(s.Tickets) >= s.Limit
+}
+
+// Stale reports whether the ticket has been in the stage for longer than the
+// stage allows as of now.
+func (s *Stage) Stale(t Ticket, now time.Time) bool {
+	return s.StaleAfter > 0 && now.Sub(t.Entered()) > s.StaleAfter
+}
+
+// Exceeded reports whether the stage holds more tickets than its limit.
+func (s *Stage) Exceeded() bool {
+	return s.Limit > 0 && len(s.Tickets) > s.Limit
+}
+
+// Assign places a ticket into the stage with a unique ID, according to the
+// stage's sort policy.
 // Existing tickets will be duplicated, but with different IDs.
+// Returns a LimitError if the stage is full.
 func (s *Stage) Assign(ticket Ticket) error {
+	if s.Full() {
+		return LimitError{Stage: s.Name, Limit: s.Limit}
+	}
+	return s.add(ticket)
+}
+
+// add appends a ticket to the stage regardless of the limit.
+func (s *Stage) add(ticket Ticket) error {
 	if ticket.ID == uuid.Nil {
 		id, err := uuid.NewUUID()
 		if err != nil {
@@ -152,19 +910,37 @@ func (s *Stage) Assign(ticket Ticket) error {
 		ticket.ID = id
 		ticket.Created = time.Now()
 	}
-	s.Tickets = append(s.Tickets, ticket)
+	if len(ticket.History) == 0 {
+		ticket.record(Event{Kind: Created, To: s.ID, At: ticket.Created})
+	}
+	s.Insert(ticket, s.slot(ticket))
 	return nil
 }
 
+// Insert places a ticket at the given index, shifting subsequent tickets down.
+// The index is clamped to the bounds of the stage.
+func (s *Stage) Insert(ticket Ticket, index int) {
+	if index < 0 {
+		index = 0
+	}
+	if index > len(s.Tickets) {
+		index = len(s.Tickets)
+	}
+	s.Tickets = append(s.Tickets, Ticket{})
+	copy(s.Tickets[index+1:], s.Tickets[index:])
+	s.Tickets[index] = ticket
+}
+
 // UnAssign removes a ticket from the stage.
 func (s *Stage) UnAssign(ticket Ticket) {
 	for ii, t := range s.Tickets {
-		if t == ticket {
+		if t.ID == ticket.ID {
 			if len(s.Tickets) == 1 {
 				s.Tickets = []Ticket{}
 			} else {
 				s.Tickets = append(s.Tickets[:ii], s.Tickets[ii+1:]...)
 			}
+			return
 		}
 	}
 }
@@ -174,7 +950,7 @@ type Stages []Stage
 
 // Swap the specified stage in the given direction.
 // Returns false when at a boundary, and therefore no swap can occur.
-func (stages *Stages) Swap(stage string, dir Direction) bool {
+func (stages *Stages) Swap(stage uuid.UUID, dir Direction) bool {
 	ii, ok := stages.Index(stage)
 	if !ok {
 		return false
@@ -186,10 +962,10 @@ func (stages *Stages) Swap(stage string, dir Direction) bool {
 	return true
 }
 
-// Find stage by name.
-func (stages *Stages) Find(name string) *Stage {
+// Find stage by ID.
+func (stages *Stages) Find(id uuid.UUID) *Stage {
 	for ii, s := range *stages {
-		if s.Name == name {
+		if s.ID == id {
 			return &(*stages)[ii]
 		}
 	}
@@ -197,9 +973,9 @@ func (stages *Stages) Find(name string) *Stage {
 }
 
 // Index returns the index postition for the stage, false if no stage exists.
-func (stages *Stages) Index(name string) (int, bool) {
+func (stages *Stages) Index(id uuid.UUID) (int, bool) {
 	for ii, s := range *stages {
-		if s.Name == name {
+		if s.ID == id {
 			return ii, true
 		}
 	}
@@ -213,10 +989,56 @@ func (s *Stage) Take(ticket Ticket) Ticket {
 	return ticket
 }
 
+// Swap the specified ticket in the given direction, where forward is towards
+// the bottom of the stage.
+// Returns false when at a boundary, or the stage is sorted by priority, and
+// therefore no swap can occur.
+func (s *Stage) Swap(ticket Ticket, dir Direction) bool {
+	ii, ok := s.Index(ticket)
+	if !ok || s.Sort == ByPriority {
+		return false
+	}
+	if bounds := ii + dir.Next(); bounds < 0 || bounds > len(s.Tickets)-1 {
+		return false
+	}
+	s.Tickets[ii], s.Tickets[ii+dir.Next()] = s.Tickets[ii+dir.Next()], s.Tickets[ii]
+	return true
+}
+
+// Move the specified ticket to the given index, shifting the tickets in
+// between.
+// Returns false when the index is out of bounds, the ticket is already there,
+// or the stage is sorted by priority.
+func (s *Stage) Move(ticket Ticket, index int) bool {
+	ii, ok := s.Index(ticket)
+	if !ok || s.Sort == ByPriority || ii == index || index < 0 || index > len(s.Tickets)-1 {
+		return false
+	}
+	t := s.Tickets[ii]
+	if ii < index {
+		copy(s.Tickets[ii:index], s.Tickets[ii+1:index+1])
+	} else {
+		copy(s.Tickets[index+1:ii+1], s.Tickets[index:ii])
+	}
+	s.Tickets[index] = t
+	return true
+}
+
+// Index returns the index position for the ticket, false if the ticket is not
+// in the stage.
+func (s *Stage) Index(ticket Ticket) (int, bool) {
+	for ii, t := range s.Tickets {
+		if t.ID == ticket.ID {
+			return ii, true
+		}
+	}
+	return 0, false
+}
+
 // Contains returns true if the specified ticket exists in the stage.
 func (s *Stage) Contains(ticket Ticket) bool {
 	for _, t := range s.Tickets {
-		if t == ticket {
+		if t.ID == ticket.ID {
 			return true
 		}
 	}
@@ -229,6 +1051,7 @@ func (s *Stage) Update(ticket Ticket) bool {
 	for ii, t := range s.Tickets {
 		if t.ID == ticket.ID {
 			s.Tickets[ii] = ticket
+			s.Reorder()
 			return true
 		}
 	}
@@ -246,6 +1069,398 @@ type Ticket struct {
 	Details string
 	// Created when the ticket was created.
 	Created time.Time
+	// Finalized when the ticket was finalized, zero for active tickets.
+	Finalized time.Time
+	// Labels is the set of catalogue labels the ticket is tagged with.
+	Labels []uuid.UUID
+	// Priority of the ticket, normal by default.
+	Priority Priority
+	// Due is the date the ticket is due by, zero for no due date.
+	// The ticket is due by the end of that day.
+	Due time.Time
+	// Assignees is the set of people, from the project directory, the ticket
+	// is assigned to.
+	Assignees []uuid.UUID
+	// Checklist is the ordered list of steps to complete the ticket.
+	Checklist []Item
+	// BlockedBy lists the tickets that must be finalized before this ticket
+	// can move past the project's gate.
+	// Blockers are added with Project.Block, and are validated again as the
+	// ticket is assigned or updated.
+	BlockedBy []uuid.UUID
+	// Comments is the discussion thread on the ticket, oldest first.
+	Comments []Comment
+	// History is the append-only log of events that happened to the ticket,
+	// oldest first.
+	History []Event
+}
+
+// Event records something that happened to a ticket.
+type Event struct {
+	Kind EventKind
+	// At is when the event happened.
+	At time.Time
+	// From is the stage the ticket left, for moved and finalized events.
+	From uuid.UUID
+	// To is the stage the ticket entered, for created, moved and restored
+	// events.
+	To uuid.UUID
+	// Changes lists the fields that changed, for edited events.
+	Changes []Change
+}
+
+// EventKind identifies what happened to a ticket.
+type EventKind int8
+
+const (
+	Created EventKind = iota
+	Edited
+	Moved
+	Finalized
+	Restored
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Created:
+		return "created"
+	case Edited:
+		return "edited"
+	case Moved:
+		return "moved"
+	case Finalized:
+		return "finalized"
+	case Restored:
+		return "restored"
+	}
+	return fmt.Sprintf("EventKind(%d)", int8(k))
+}
+
+// Change records the value of a field before and after an edit.
+type Change struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// record appends an event to the history, timestamping it now if the event
+// has no time.
+// A fresh slice is allocated so that copies of the ticket are unaffected.
+func (t *Ticket) record(e Event) {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	t.History = append(t.Clone().History, e)
+}
+
+// Comment is a single remark in a ticket's discussion thread.
+type Comment struct {
+	ID uuid.UUID
+	// Author is the name of the person who wrote the comment.
+	Author string
+	Text   string
+	// Created when the comment was written.
+	Created time.Time
+	// Edited when the comment was last changed, zero if never.
+	Edited time.Time
+}
+
+// AddComment appends a comment to the thread.
+func (t *Ticket) AddComment(author, text string) Comment {
+	c := Comment{
+		ID:      uuid.New(),
+		Author:  author,
+		Text:    text,
+		Created: time.Now(),
+	}
+	t.Comments = append(t.Clone().Comments, c)
+	return c
+}
+
+// EditComment replaces the text of a comment.
+// It is an error to edit a comment that does not exist, or that belongs to
+// someone other than author.
+func (t *Ticket) EditComment(id uuid.UUID, author, text string) error {
+	ii, err := t.comment(id, author)
+	if err != nil {
+		return err
+	}
+	t.Comments = t.Clone().Comments
+	t.Comments[ii].Text = text
+	t.Comments[ii].Edited = time.Now()
+	return nil
+}
+
+// DeleteComment removes a comment from the thread.
+// It is an error to delete a comment that does not exist, or that belongs to
+// someone other than author.
+func (t *Ticket) DeleteComment(id uuid.UUID, author string) error {
+	ii, err := t.comment(id, author)
+	if err != nil {
+		return err
+	}
+	comments := make([]Comment, 0, len(t.Comments)-1)
+	comments = append(comments, t.Comments[:ii]...)
+	t.Comments = append(comments, t.Comments[ii+1:]...)
+	return nil
+}
+
+// comment returns the index of the comment written by author.
+func (t *Ticket) comment(id uuid.UUID, author string) (int, error) {
+	for ii, c := range t.Comments {
+		if c.ID == id {
+			if c.Author != author {
+				return 0, fmt.Errorf("comment belongs to %q", c.Author)
+			}
+			return ii, nil
+		}
+	}
+	return 0, fmt.Errorf("comment does not exist: %v", id)
+}
+
+// Item is a single step in a ticket's checklist.
+type Item struct {
+	Text string
+	Done bool
+}
+
+// Progress returns the number of checklist items done, out of the total.
+func (t Ticket) Progress() (done, total int) {
+	for _, item := range t.Checklist {
+		if item.Done {
+			done++
+		}
+	}
+	return done, len(t.Checklist)
+}
+
+// The checklist methods copy the checklist before modifying it so that copies
+// of the ticket are unaffected.
+
+// AddItem appends an open item to the checklist.
+func (t *Ticket) AddItem(text string) {
+	t.Checklist = append(t.Clone().Checklist, Item{Text: text})
+}
+
+// CheckItem marks the item at index ii as done or not.
+func (t *Ticket) CheckItem(ii int, done bool) {
+	if ii < 0 || ii >= len(t.Checklist) {
+		return
+	}
+	t.Checklist = t.Clone().Checklist
+	t.Checklist[ii].Done = done
+}
+
+// MoveItem swaps the item at index ii with its neighbour in the given
+// direction.
+// Returns false when at a boundary, and therefore no move can occur.
+func (t *Ticket) MoveItem(ii int, dir Direction) bool {
+	jj := ii + dir.Next()
+	if ii < 0 || ii >= len(t.Checklist) || jj < 0 || jj >= len(t.Checklist) {
+		return false
+	}
+	t.Checklist = t.Clone().Checklist
+	t.Checklist[ii], t.Checklist[jj] = t.Checklist[jj], t.Checklist[ii]
+	return true
+}
+
+// RemoveItem deletes the item at index ii from the checklist.
+func (t *Ticket) RemoveItem(ii int) {
+	if ii < 0 || ii >= len(t.Checklist) {
+		return
+	}
+	checklist := make([]Item, 0, len(t.Checklist)-1)
+	checklist = append(checklist, t.Checklist[:ii]...)
+	t.Checklist = append(checklist, t.Checklist[ii+1:]...)
+}
+
+// AssignedTo returns true if the ticket is assigned to the given person.
+func (t Ticket) AssignedTo(person uuid.UUID) bool {
+	for _, id := range t.Assignees {
+		if id == person {
+			return true
+		}
+	}
+	return false
+}
+
+// Assign the ticket to a person, if not already assigned.
+func (t *Ticket) Assign(person uuid.UUID) {
+	if !t.AssignedTo(person) {
+		t.Assignees = append(t.Clone().Assignees, person)
+	}
+}
+
+// DependsOn returns true if the ticket is blocked by the given ticket.
+func (t Ticket) DependsOn(blocker uuid.UUID) bool {
+	for _, id := range t.BlockedBy {
+		if id == blocker {
+			return true
+		}
+	}
+	return false
+}
+
+// Unblock removes a blocker from the ticket.
+// A fresh slice is allocated so that copies of the ticket are unaffected.
+func (t *Ticket) Unblock(blocker uuid.UUID) {
+	t.BlockedBy = without(t.BlockedBy, blocker)
+}
+
+// Unassign a person from the ticket.
+// A fresh slice is allocated so that copies of the ticket are unaffected.
+func (t *Ticket) Unassign(person uuid.UUID) {
+	t.Assignees = without(t.Assignees, person)
+}
+
+// Entered returns when the ticket entered the stage it is currently in,
+// according to its history.
+// Tickets without history are considered to have entered when they were
+// created.
+func (t Ticket) Entered() time.Time {
+	for ii := len(t.History) - 1; ii >= 0; ii-- {
+		switch e := t.History[ii]; e.Kind {
+		case Created, Moved, Restored:
+			return e.At
+		}
+	}
+	return t.Created
+}
+
+// Overdue reports whether the ticket's due date has passed as of now.
+func (t Ticket) Overdue(now time.Time) bool {
+	return !t.Due.IsZero() && !now.Before(t.Due.AddDate(0, 0, 1))
+}
+
+// DueWithin reports whether the ticket falls due within d of now, without
+// being overdue.
+func (t Ticket) DueWithin(now time.Time, d time.Duration) bool {
+	return !t.Due.IsZero() && !t.Overdue(now) && t.Due.Before(now.Add(d))
+}
+
+// Priority ranks the urgency of a ticket.
+type Priority int8
+
+const (
+	Low Priority = iota - 1
+	Normal
+	High
+	Critical
+)
+
+// Priorities lists every priority, highest first.
+var Priorities = []Priority{Critical, High, Normal, Low}
+
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	case Critical:
+		return "critical"
+	}
+	return fmt.Sprintf("Priority(%d)", int8(p))
+}
+
+// ParsePriority returns the priority with the given name.
+func ParsePriority(name string) (Priority, error) {
+	for _, p := range Priorities {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return Normal, fmt.Errorf("unknown priority: %q", name)
+}
+
+// Outranks reports whether the ticket should be worked before other: it has a
+// higher priority, or the same priority and is older.
+func (t Ticket) Outranks(other Ticket) bool {
+	if t.Priority != other.Priority {
+		return t.Priority > other.Priority
+	}
+	return t.Created.Before(other.Created)
+}
+
+// Tagged returns true if the ticket carries the given label.
+func (t Ticket) Tagged(label uuid.UUID) bool {
+	for _, id := range t.Labels {
+		if id == label {
+			return true
+		}
+	}
+	return false
+}
+
+// Tag adds a label to the ticket, if not already present.
+func (t *Ticket) Tag(label uuid.UUID) {
+	if !t.Tagged(label) {
+		t.Labels = append(t.Clone().Labels, label)
+	}
+}
+
+// Untag removes a label from the ticket.
+// A fresh slice is allocated so that copies of the ticket are unaffected.
+func (t *Ticket) Untag(label uuid.UUID) {
+	t.Labels = without(t.Labels, label)
+}
+
+// without returns ids less the given id, allocating a new slice if the id is
+// present.
+func without(ids []uuid.UUID, id uuid.UUID) []uuid.UUID {
+	for ii := range ids {
+		if ids[ii] == id {
+			out := make([]uuid.UUID, 0, len(ids)-1)
+			out = append(out, ids[:ii]...)
+			return append(out, ids[ii+1:]...)
+		}
+	}
+	return ids
+}
+
+// Clone a ticket ensuring all data is copied.
+func (t Ticket) Clone() Ticket {
+	if t.Labels != nil {
+		t.Labels = append([]uuid.UUID(nil), t.Labels...)
+	}
+	if t.Assignees != nil {
+		t.Assignees = append([]uuid.UUID(nil), t.Assignees...)
+	}
+	if t.Checklist != nil {
+		t.Checklist = append([]Item(nil), t.Checklist...)
+	}
+	if t.BlockedBy != nil {
+		t.BlockedBy = append([]uuid.UUID(nil), t.BlockedBy...)
+	}
+	if t.Comments != nil {
+		t.Comments = append([]Comment(nil), t.Comments...)
+	}
+	// Events are never modified once recorded, so their changes can be
+	// shared.
+	if t.History != nil {
+		t.History = append([]Event(nil), t.History...)
+	}
+	return t
+}
+
+// Eq reports whether two tickets hold identical data.
+func (t Ticket) Eq(other Ticket) bool {
+	return t.ID == other.ID &&
+		ids(t.Labels).eq(other.Labels) &&
+		ids(t.Assignees).eq(other.Assignees) &&
+		checklist(t.Checklist).eq(other.Checklist) &&
+		ids(t.BlockedBy).eq(other.BlockedBy) &&
+		comments(t.Comments).eq(other.Comments) &&
+		events(t.History).eq(other.History) &&
+		t.Title == other.Title &&
+		t.Summary == other.Summary &&
+		t.Details == other.Details &&
+		t.Priority == other.Priority &&
+		t.Due.Equal(other.Due) &&
+		t.Created.Equal(other.Created) &&
+		t.Finalized.Equal(other.Finalized)
 }
 
 // Direction encodes mutually exclusive directions.
@@ -290,14 +1505,26 @@ func (p Project) Clone() Project {
 	var (
 		stages    = make([]Stage, len(p.Stages))
 		finalized = make([]Ticket, len(p.Finalized))
+		labels    = make([]Label, len(p.Labels))
+		people    = make([]Person, len(p.People))
 	)
-	copy(finalized, p.Finalized)
+	copy(people, p.People)
+	for ii, t := range p.Finalized {
+		finalized[ii] = t.Clone()
+	}
+	copy(labels, p.Labels)
 	for ii, s := range p.Stages {
 		tickets := make([]Ticket, len(s.Tickets))
-		copy(tickets, s.Tickets)
+		for jj, t := range s.Tickets {
+			tickets[jj] = t.Clone()
+		}
 		stages[ii] = Stage{
-			Name:    s.Name,
-			Tickets: tickets,
+			ID:         s.ID,
+			Name:       s.Name,
+			Tickets:    tickets,
+			Limit:      s.Limit,
+			Sort:       s.Sort,
+			StaleAfter: s.StaleAfter,
 		}
 	}
 	return Project{
@@ -305,32 +1532,176 @@ func (p Project) Clone() Project {
 		Name:      p.Name,
 		Stages:    stages,
 		Finalized: finalized,
+		Limits:    p.Limits,
+		Labels:    labels,
+		People:    people,
+
+		StrictChecklists: p.StrictChecklists,
+		Gate:             p.Gate,
+		Blocking:         p.Blocking,
+		Revision:         p.Revision,
 	}
 }
 
 func (p *Project) Eq(other *Project) bool {
 	return p.ID == other.ID &&
 		p.Name == other.Name &&
-		p.Stages.Eq(other.Stages)
+		p.Limits == other.Limits &&
+		p.Labels.Eq(other.Labels) &&
+		p.People.Eq(other.People) &&
+		p.StrictChecklists == other.StrictChecklists &&
+		p.Gate == other.Gate &&
+		p.Blocking == other.Blocking &&
+		p.Revision == other.Revision &&
+		p.Stages.Eq(other.Stages) &&
+		tickets(p.Finalized).eq(other.Finalized)
 }
 
-func (s Stages) Eq(other Stages) bool {
-	for ii := range s {
-		if !s[ii].Eq(other[ii]) {
+// tickets is a list of Ticket.
+type tickets []Ticket
+
+func (l tickets) eq(other tickets) bool {
+	if len(l) != len(other) {
+		return false
+	}
+	for ii := range l {
+		if !l[ii].Eq(other[ii]) {
 			return false
 		}
 	}
 	return true
 }
 
-func (s Stage) Eq(other Stage) bool {
-	if len(s.Tickets) != len(other.Tickets) {
+// ids is a list of IDs.
+type ids []uuid.UUID
+
+func (l ids) eq(other ids) bool {
+	if len(l) != len(other) {
 		return false
 	}
-	for ii, t := range s.Tickets {
-		if t != other.Tickets[ii] {
+	for ii := range l {
+		if l[ii] != other[ii] {
+			return false
+		}
+	}
+	return true
+}
+
+// checklist is a list of Item.
+type checklist []Item
+
+func (l checklist) eq(other checklist) bool {
+	if len(l) != len(other) {
+		return false
+	}
+	for ii := range l {
+		if l[ii] != other[ii] {
 			return false
 		}
 	}
-	return s.Name == other.Name
+	return true
+}
+
+// events is a list of Event.
+type events []Event
+
+func (l events) eq(other events) bool {
+	if len(l) != len(other) {
+		return false
+	}
+	for ii := range l {
+		if !l[ii].Eq(other[ii]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e Event) Eq(other Event) bool {
+	if len(e.Changes) != len(other.Changes) {
+		return false
+	}
+	for ii := range e.Changes {
+		if e.Changes[ii] != other.Changes[ii] {
+			return false
+		}
+	}
+	return e.Kind == other.Kind &&
+		e.At.Equal(other.At) &&
+		e.From == other.From &&
+		e.To == other.To
+}
+
+// comments is a list of Comment.
+type comments []Comment
+
+func (l comments) eq(other comments) bool {
+	if len(l) != len(other) {
+		return false
+	}
+	for ii := range l {
+		if !l[ii].Eq(other[ii]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Comment) Eq(other Comment) bool {
+	return c.ID == other.ID &&
+		c.Author == other.Author &&
+		c.Text == other.Text &&
+		c.Created.Equal(other.Created) &&
+		c.Edited.Equal(other.Edited)
+}
+
+// People is a list of Person.
+type People []Person
+
+func (l People) Eq(other People) bool {
+	if len(l) != len(other) {
+		return false
+	}
+	for ii := range l {
+		if l[ii] != other[ii] {
+			return false
+		}
+	}
+	return true
+}
+
+// Labels is a list of Label.
+type Labels []Label
+
+func (l Labels) Eq(other Labels) bool {
+	if len(l) != len(other) {
+		return false
+	}
+	for ii := range l {
+		if l[ii] != other[ii] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Stages) Eq(other Stages) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for ii := range s {
+		if !s[ii].Eq(other[ii]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Stage) Eq(other Stage) bool {
+	return tickets(s.Tickets).eq(other.Tickets) &&
+		s.ID == other.ID &&
+		s.Name == other.Name &&
+		s.Limit == other.Limit &&
+		s.Sort == other.Sort &&
+		s.StaleAfter == other.StaleAfter
 }
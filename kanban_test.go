@@ -0,0 +1,790 @@
+package kanban
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// titles lists the titles of the tickets in a stage, in order.
+func titles(s Stage) []string {
+	var titles []string
+	for _, t := range s.Tickets {
+		titles = append(titles, t.Title)
+	}
+	return titles
+}
+
+func TestMoveTicketToStageByPriority(t *testing.T) {
+	var p Project
+	todo := p.MakeStage("todo")
+	sorted := p.MakeStage("sorted")
+	p.Stages[1].Sort = ByPriority
+	now := time.Now()
+	for _, ticket := range []Ticket{
+		{ID: uuid.New(), Title: "high", Priority: High, Created: now},
+		{ID: uuid.New(), Title: "normal", Priority: Normal, Created: now},
+	} {
+		if err := p.AssignTicket(sorted, ticket); err != nil {
+			t.Fatalf("assigning ticket: %v", err)
+		}
+	}
+	low := Ticket{ID: uuid.New(), Title: "low", Priority: Low, Created: now}
+	if err := p.AssignTicket(todo, low); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	// Dropped at the top, the ticket still goes below those it is outranked
+	// by.
+	if err := p.MoveTicketToStage(low, sorted, 0); err != nil {
+		t.Fatalf("moving ticket: %v", err)
+	}
+	if got := titles(p.Stages[1]); len(got) != 3 || got[2] != "low" {
+		t.Fatalf("want low priority ticket last, got %v", got)
+	}
+	if p.MoveTicketToTop(low) {
+		t.Fatalf("want tickets in a sorted stage to refuse manual moves")
+	}
+}
+
+func TestStageLimits(t *testing.T) {
+	var p Project
+	todo := p.MakeStage("todo")
+	p.Stages[0].Limit = 1
+	if err := p.AssignTicket(todo, Ticket{ID: uuid.New(), Title: "first"}); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	if !p.Stages[0].Full() || p.Stages[0].Exceeded() {
+		t.Fatalf("want stage at its limit full but not exceeded")
+	}
+	// Soft limits let the stage go over, flagging it as exceeded.
+	if err := p.AssignTicket(todo, Ticket{ID: uuid.New(), Title: "second"}); err != nil {
+		t.Fatalf("assigning ticket over a soft limit: %v", err)
+	}
+	if !p.Stages[0].Exceeded() {
+		t.Fatalf("want stage over its limit exceeded")
+	}
+	p.Limits = Hard
+	err := p.AssignTicket(todo, Ticket{ID: uuid.New(), Title: "third"})
+	if _, ok := err.(LimitError); !ok {
+		t.Fatalf("want LimitError over a hard limit, got %v", err)
+	}
+	if got := len(p.Stages[0].Tickets); got != 2 {
+		t.Fatalf("want ticket refused, got %d tickets", got)
+	}
+}
+
+func TestRemoveStageLimits(t *testing.T) {
+	setup := func(t *testing.T) (*Project, uuid.UUID, uuid.UUID) {
+		t.Helper()
+		var p Project
+		todo, doing := p.MakeStage("todo"), p.MakeStage("doing")
+		p.Stages[1].Limit = 2
+		for ii, stage := range []uuid.UUID{todo, todo, doing} {
+			if err := p.AssignTicket(stage, Ticket{ID: uuid.New(), Title: string(rune('a' + ii))}); err != nil {
+				t.Fatalf("assigning ticket: %v", err)
+			}
+		}
+		return &p, todo, doing
+	}
+	t.Run("hard", func(t *testing.T) {
+		p, todo, doing := setup(t)
+		p.Limits = Hard
+		err := p.RemoveStage(todo, doing)
+		if _, ok := err.(LimitError); !ok {
+			t.Fatalf("want LimitError removing into a stage it would overfill, got %v", err)
+		}
+		if len(p.Stages) != 2 || len(p.Stages[0].Tickets) != 2 || len(p.Stages[1].Tickets) != 1 {
+			t.Fatalf("want project unchanged after a refused removal")
+		}
+		p.Stages[1].Limit = 3
+		if err := p.RemoveStage(todo, doing); err != nil {
+			t.Fatalf("removing stage into a stage with room: %v", err)
+		}
+		if len(p.Stages) != 1 || !p.Stages[0].Full() {
+			t.Fatalf("want tickets moved up to the limit, got %d tickets", len(p.Stages[0].Tickets))
+		}
+	})
+	t.Run("soft", func(t *testing.T) {
+		p, todo, doing := setup(t)
+		if err := p.RemoveStage(todo, doing); err != nil {
+			t.Fatalf("removing stage over a soft limit: %v", err)
+		}
+		if len(p.Stages) != 1 || !p.Stages[0].Exceeded() {
+			t.Fatalf("want tickets moved over a soft limit, got %d tickets", len(p.Stages[0].Tickets))
+		}
+	})
+}
+
+func TestLabels(t *testing.T) {
+	var p Project
+	todo := p.MakeStage("todo")
+	bug := p.MakeLabel("bug", color.NRGBA{R: 255, A: 255})
+	if l, ok := p.FindLabel(bug); !ok || l.Name != "bug" {
+		t.Fatalf("want label found, got %+v", l)
+	}
+	open := Ticket{ID: uuid.New(), Title: "open", Labels: []uuid.UUID{bug}}
+	closed := Ticket{ID: uuid.New(), Title: "closed", Labels: []uuid.UUID{bug}}
+	for _, ticket := range []Ticket{open, closed} {
+		if err := p.AssignTicket(todo, ticket); err != nil {
+			t.Fatalf("assigning ticket: %v", err)
+		}
+	}
+	if err := p.FinalizeTicket(closed); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	p.RemoveLabel(bug)
+	if _, ok := p.FindLabel(bug); ok {
+		t.Fatalf("want label removed from the catalogue")
+	}
+	if got, _ := p.FindTicket(open.ID); got.Tagged(bug) {
+		t.Fatalf("want label removed from the ticket")
+	}
+	if p.Finalized[0].Tagged(bug) {
+		t.Fatalf("want label removed from the finalized ticket")
+	}
+}
+
+func TestPeople(t *testing.T) {
+	var p Project
+	todo := p.MakeStage("todo")
+	ada := p.MakePerson("Ada Lovelace")
+	if person, ok := p.FindPerson(ada); !ok || person.Initials() != "AL" {
+		t.Fatalf("want person found with initials AL, got %+v", person)
+	}
+	if person, ok := p.FindPersonByName("ada lovelace"); !ok || person.ID != ada {
+		t.Fatalf("want person found by name ignoring case, got %+v", person)
+	}
+	ticket := Ticket{ID: uuid.New(), Title: "ticket", Assignees: []uuid.UUID{ada}}
+	if err := p.AssignTicket(todo, ticket); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	p.RemovePerson(ada)
+	if _, ok := p.FindPerson(ada); ok {
+		t.Fatalf("want person removed from the directory")
+	}
+	if got, _ := p.FindTicket(ticket.ID); got.AssignedTo(ada) {
+		t.Fatalf("want person unassigned from the ticket")
+	}
+}
+
+func TestCanBlock(t *testing.T) {
+	var p Project
+	todo := p.MakeStage("todo")
+	a := Ticket{ID: uuid.New(), Title: "a"}
+	b := Ticket{ID: uuid.New(), Title: "b", BlockedBy: []uuid.UUID{a.ID}}
+	for _, ticket := range []Ticket{a, b} {
+		if err := p.AssignTicket(todo, ticket); err != nil {
+			t.Fatalf("assigning ticket: %v", err)
+		}
+	}
+	for _, tt := range []struct {
+		ticket, blocker uuid.UUID
+		path            string
+	}{
+		{ticket: a.ID, blocker: a.ID, path: "a a"},
+		{ticket: a.ID, blocker: b.ID, path: "a b a"},
+	} {
+		err := p.CanBlock(tt.ticket, tt.blocker)
+		cycle, ok := err.(CycleError)
+		if !ok {
+			t.Fatalf("want CycleError, got %v", err)
+		}
+		if got := strings.Join(cycle.Path, " "); got != tt.path {
+			t.Errorf("want cycle %q, got %q", tt.path, got)
+		}
+	}
+	if err := p.CanBlock(a.ID, uuid.New()); err == nil {
+		t.Fatalf("want unknown blocker refused")
+	}
+	c := Ticket{ID: uuid.New(), Title: "c"}
+	if err := p.AssignTicket(todo, c); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	if err := p.CanBlock(c.ID, b.ID); err != nil {
+		t.Fatalf("want blocker without a cycle allowed, got %v", err)
+	}
+}
+
+func TestRestoreTicketPastGate(t *testing.T) {
+	var p Project
+	todo := p.MakeStage("todo")
+	done := p.MakeStage("done")
+	p.Gate = todo
+	p.Blocking = Hard
+	blocker := Ticket{ID: uuid.New(), Title: "blocker"}
+	ticket := Ticket{ID: uuid.New(), Title: "ticket", BlockedBy: []uuid.UUID{blocker.ID}}
+	for _, each := range []Ticket{blocker, ticket} {
+		if err := p.AssignTicket(todo, each); err != nil {
+			t.Fatalf("assigning ticket: %v", err)
+		}
+	}
+	if err := p.FinalizeTicket(ticket); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	// Still blocked once archived, the ticket cannot be restored past the
+	// gate.
+	if _, ok := p.RestoreTicket(ticket, done).(BlockedError); !ok {
+		t.Fatalf("want BlockedError restoring past the gate")
+	}
+	if len(p.Finalized) != 1 {
+		t.Fatalf("want ticket kept in the archive")
+	}
+	if err := p.RestoreTicket(ticket, todo); err != nil {
+		t.Fatalf("want ticket restored before the gate, got %v", err)
+	}
+}
+
+func TestCloneEq(t *testing.T) {
+	p := Project{ID: uuid.New(), Name: "project", Revision: 3}
+	todo := p.MakeStage("todo")
+	ticket := Ticket{ID: uuid.New(), Title: "ticket"}
+	if err := p.AssignTicket(todo, ticket); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	if err := p.FinalizeTicket(ticket); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	clone := p.Clone()
+	if !p.Eq(&clone) {
+		t.Fatalf("want clone equal to the project")
+	}
+	// Saving elsewhere advances the revision, which is enough to tell the
+	// projects apart.
+	clone.Revision++
+	if p.Eq(&clone) {
+		t.Fatalf("want projects at different revisions unequal")
+	}
+	clone = p.Clone()
+	clone.Finalized[0].Title = "edited"
+	if p.Eq(&clone) || p.Finalized[0].Title != "ticket" {
+		t.Fatalf("want finalized tickets copied and compared")
+	}
+}
+
+func TestTicketsByDue(t *testing.T) {
+	var p Project
+	todo := p.MakeStage("todo")
+	done := p.MakeStage("done")
+	day := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		stage  uuid.UUID
+		ticket Ticket
+	}{
+		{stage: todo, ticket: Ticket{Title: "undated"}},
+		{stage: todo, ticket: Ticket{Title: "later", Due: day.AddDate(0, 0, 2)}},
+		{stage: done, ticket: Ticket{Title: "also undated"}},
+		{stage: done, ticket: Ticket{Title: "sooner", Due: day}},
+	} {
+		tt.ticket.ID = uuid.New()
+		if err := p.AssignTicket(tt.stage, tt.ticket); err != nil {
+			t.Fatalf("assigning ticket: %v", err)
+		}
+	}
+	var got []string
+	for _, ticket := range p.TicketsByDue() {
+		got = append(got, ticket.Title)
+	}
+	want := []string{"sooner", "later", "undated", "also undated"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	var p Project
+	todo := p.MakeStage("todo")
+	a := Ticket{ID: uuid.New(), Title: "a"}
+	if err := p.AssignTicket(todo, a); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	b := Ticket{ID: uuid.New(), Title: "b"}
+	for ii := 0; ii < 2; ii++ {
+		if err := p.Block(&b, a.ID); err != nil {
+			t.Fatalf("blocking draft ticket: %v", err)
+		}
+	}
+	if len(b.BlockedBy) != 1 {
+		t.Fatalf("want blocker linked once, got %d", len(b.BlockedBy))
+	}
+	if err := p.AssignTicket(todo, b); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	if blocks := p.Blocks(a); len(blocks) != 1 || blocks[0].ID != b.ID {
+		t.Fatalf("want a to block b, got %v", blocks)
+	}
+	// Linking around Block is validated all the same.
+	a, _ = p.FindTicket(a.ID)
+	if _, ok := p.Block(&a, b.ID).(CycleError); !ok || a.DependsOn(b.ID) {
+		t.Fatalf("want cycle refused by Block")
+	}
+	a.BlockedBy = []uuid.UUID{b.ID}
+	if _, ok := p.UpdateTicket(a).(CycleError); !ok {
+		t.Fatalf("want cycle refused by UpdateTicket")
+	}
+	if got, _ := p.FindTicket(a.ID); len(got.BlockedBy) != 0 {
+		t.Fatalf("want ticket unchanged, got %d blockers", len(got.BlockedBy))
+	}
+	c := Ticket{ID: uuid.New(), Title: "c", BlockedBy: []uuid.UUID{uuid.New()}}
+	if err := p.AssignTicket(todo, c); err == nil {
+		t.Fatalf("want unknown blocker refused by AssignTicket")
+	}
+}
+
+// stage returns a project with a single stage holding the tickets a, b, c
+// and d, in that order.
+func stage(t *testing.T) (*Project, map[string]Ticket) {
+	t.Helper()
+	p := &Project{}
+	todo := p.MakeStage("todo")
+	tickets := make(map[string]Ticket)
+	for _, title := range []string{"a", "b", "c", "d"} {
+		ticket := Ticket{ID: uuid.New(), Title: title}
+		if err := p.AssignTicket(todo, ticket); err != nil {
+			t.Fatalf("assigning ticket: %v", err)
+		}
+		tickets[title] = ticket
+	}
+	tickets["missing"] = Ticket{ID: uuid.New(), Title: "missing"}
+	return p, tickets
+}
+
+func TestMoveTicket(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		ticket string
+		move   func(p *Project, t Ticket) bool
+		moved  bool
+		want   string
+	}{
+		{
+			name:   "forward",
+			ticket: "b",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicket(t, Forward) },
+			moved:  true,
+			want:   "a c b d",
+		},
+		{
+			name:   "backward",
+			ticket: "b",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicket(t, Backward) },
+			moved:  true,
+			want:   "b a c d",
+		},
+		{
+			name:   "first backward",
+			ticket: "a",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicket(t, Backward) },
+			want:   "a b c d",
+		},
+		{
+			name:   "last forward",
+			ticket: "d",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicket(t, Forward) },
+			want:   "a b c d",
+		},
+		{
+			name:   "down to an index",
+			ticket: "a",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicketTo(t, 2) },
+			moved:  true,
+			want:   "b c a d",
+		},
+		{
+			name:   "up to an index",
+			ticket: "d",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicketTo(t, 1) },
+			moved:  true,
+			want:   "a d b c",
+		},
+		{
+			name:   "same index",
+			ticket: "c",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicketTo(t, 2) },
+			want:   "a b c d",
+		},
+		{
+			name:   "index past the end",
+			ticket: "a",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicketTo(t, 4) },
+			want:   "a b c d",
+		},
+		{
+			name:   "negative index",
+			ticket: "b",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicketTo(t, -1) },
+			want:   "a b c d",
+		},
+		{
+			name:   "last to top",
+			ticket: "d",
+			move:   (*Project).MoveTicketToTop,
+			moved:  true,
+			want:   "d a b c",
+		},
+		{
+			name:   "first to bottom",
+			ticket: "a",
+			move:   (*Project).MoveTicketToBottom,
+			moved:  true,
+			want:   "b c d a",
+		},
+		{
+			name:   "first to top",
+			ticket: "a",
+			move:   (*Project).MoveTicketToTop,
+			want:   "a b c d",
+		},
+		{
+			name:   "last to bottom",
+			ticket: "d",
+			move:   (*Project).MoveTicketToBottom,
+			want:   "a b c d",
+		},
+		{
+			name:   "missing ticket",
+			ticket: "missing",
+			move:   (*Project).MoveTicketToTop,
+			want:   "a b c d",
+		},
+		{
+			name:   "missing ticket forward",
+			ticket: "missing",
+			move:   func(p *Project, t Ticket) bool { return p.MoveTicket(t, Forward) },
+			want:   "a b c d",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, tickets := stage(t)
+			if moved := tt.move(p, tickets[tt.ticket]); moved != tt.moved {
+				t.Errorf("want moved %v, got %v", tt.moved, moved)
+			}
+			if got := strings.Join(titles(p.Stages[0]), " "); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMoveTicketToStageClamps(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		index int
+		want  string
+	}{
+		{name: "negative index", index: -5, want: "x a b c d"},
+		{name: "index past the end", index: 10, want: "a b c d x"},
+		{name: "index in bounds", index: 2, want: "a b x c d"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := stage(t)
+			done := p.MakeStage("done")
+			x := Ticket{ID: uuid.New(), Title: "x"}
+			if err := p.AssignTicket(done, x); err != nil {
+				t.Fatalf("assigning ticket: %v", err)
+			}
+			if err := p.MoveTicketToStage(x, p.Stages[0].ID, tt.index); err != nil {
+				t.Fatalf("moving ticket: %v", err)
+			}
+			if got := strings.Join(titles(p.Stages[0]), " "); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFinalizeTicket(t *testing.T) {
+	p := Project{StrictChecklists: true}
+	todo := p.MakeStage("todo")
+	ticket := Ticket{ID: uuid.New(), Title: "ticket"}
+	ticket.AddItem("step")
+	if err := p.AssignTicket(todo, ticket); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	// A copy with the item checked off does not get past the stored ticket's
+	// open item.
+	stale := ticket.Clone()
+	stale.CheckItem(0, true)
+	if _, ok := p.FinalizeTicket(stale).(ChecklistError); !ok {
+		t.Fatalf("want ChecklistError for the stored ticket's open item")
+	}
+	if err := p.FinalizeTicket(Ticket{ID: uuid.New()}); err == nil {
+		t.Fatalf("want error finalizing a missing ticket")
+	}
+	if err := p.UpdateTicket(stale); err != nil {
+		t.Fatalf("updating ticket: %v", err)
+	}
+	if err := p.FinalizeTicket(ticket); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	if len(p.Finalized) != 1 || p.Finalized[0].Finalized.IsZero() {
+		t.Fatalf("want ticket finalized")
+	}
+	if err := p.FinalizeTicket(ticket); err == nil {
+		t.Fatalf("want error finalizing a ticket twice")
+	}
+}
+
+// items returns the text of each checklist item, with a "x" prefix when done.
+func items(t Ticket) string {
+	var s []string
+	for _, item := range t.Checklist {
+		if item.Done {
+			s = append(s, "x"+item.Text)
+		} else {
+			s = append(s, item.Text)
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+func TestChecklist(t *testing.T) {
+	var ticket Ticket
+	if done, total := ticket.Progress(); done != 0 || total != 0 {
+		t.Fatalf("want no progress without a checklist, got %d/%d", done, total)
+	}
+	for _, text := range []string{"a", "b", "c"} {
+		ticket.AddItem(text)
+	}
+	copied := ticket
+	for _, tt := range []struct {
+		name  string
+		do    func()
+		want  string
+		done  int
+		total int
+	}{
+		{name: "added", do: func() {}, want: "a b c", total: 3},
+		{name: "check", do: func() { ticket.CheckItem(1, true) }, want: "a xb c", done: 1, total: 3},
+		{name: "check out of range", do: func() { ticket.CheckItem(3, true) }, want: "a xb c", done: 1, total: 3},
+		{name: "move down", do: func() { ticket.MoveItem(1, Forward) }, want: "a c xb", done: 1, total: 3},
+		{name: "move past the end", do: func() { ticket.MoveItem(2, Forward) }, want: "a c xb", done: 1, total: 3},
+		{name: "move up", do: func() { ticket.MoveItem(1, Backward) }, want: "c a xb", done: 1, total: 3},
+		{name: "uncheck", do: func() { ticket.CheckItem(2, false) }, want: "c a b", total: 3},
+		{name: "remove", do: func() { ticket.RemoveItem(0) }, want: "a b", total: 2},
+		{name: "remove out of range", do: func() { ticket.RemoveItem(-1) }, want: "a b", total: 2},
+		{name: "check all", do: func() { ticket.CheckItem(0, true); ticket.CheckItem(1, true) }, want: "xa xb", done: 2, total: 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.do()
+			if got := items(ticket); got != tt.want {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+			if done, total := ticket.Progress(); done != tt.done || total != tt.total {
+				t.Fatalf("want progress %d/%d, got %d/%d", tt.done, tt.total, done, total)
+			}
+		})
+	}
+	if got := items(copied); got != "a b c" {
+		t.Fatalf("want copy of the ticket unaffected, got %q", got)
+	}
+}
+
+func TestFinalizeOpenChecklist(t *testing.T) {
+	var p Project
+	todo := p.MakeStage("todo")
+	ticket := Ticket{ID: uuid.New(), Title: "ticket"}
+	ticket.AddItem("a")
+	ticket.AddItem("b")
+	ticket.CheckItem(0, true)
+	if err := p.AssignTicket(todo, ticket); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	p.StrictChecklists = true
+	err := p.FinalizeTicket(ticket)
+	if want := (ChecklistError{Ticket: "ticket", Open: 1}); err != want {
+		t.Fatalf("want %v, got %v", want, err)
+	}
+	if len(p.Finalized) != 0 || len(p.Stages[0].Tickets) != 1 {
+		t.Fatalf("want ticket left on the board")
+	}
+	p.StrictChecklists = false
+	if err := p.FinalizeTicket(ticket); err != nil {
+		t.Fatalf("finalizing with open items when not strict: %v", err)
+	}
+	if len(p.Finalized) != 1 {
+		t.Fatalf("want ticket finalized")
+	}
+}
+
+func TestComments(t *testing.T) {
+	var ticket Ticket
+	first := ticket.AddComment("alice", "first")
+	second := ticket.AddComment("bob", "second")
+	if len(ticket.Comments) != 2 || ticket.Comments[0] != first || ticket.Comments[1] != second {
+		t.Fatalf("want comments appended in order, got %+v", ticket.Comments)
+	}
+	if first.ID == second.ID || first.Created.IsZero() || !first.Edited.IsZero() {
+		t.Fatalf("want new comment with a unique ID and creation time, got %+v", first)
+	}
+	copied := ticket
+	if err := ticket.EditComment(first.ID, "alice", "edited"); err != nil {
+		t.Fatalf("editing comment: %v", err)
+	}
+	if got := ticket.Comments[0]; got.Text != "edited" || got.Edited.IsZero() {
+		t.Fatalf("want comment text replaced and marked edited, got %+v", got)
+	}
+	if got := copied.Comments[0]; got.Text != "first" {
+		t.Fatalf("want copy of the ticket unaffected, got %q", got.Text)
+	}
+	if err := ticket.DeleteComment(first.ID, "alice"); err != nil {
+		t.Fatalf("deleting comment: %v", err)
+	}
+	if len(ticket.Comments) != 1 || ticket.Comments[0].ID != second.ID {
+		t.Fatalf("want only the deleted comment removed, got %+v", ticket.Comments)
+	}
+	if len(copied.Comments) != 2 {
+		t.Fatalf("want copy of the ticket unaffected, got %d comments", len(copied.Comments))
+	}
+}
+
+func TestCommentAuthor(t *testing.T) {
+	var ticket Ticket
+	c := ticket.AddComment("alice", "mine")
+	for _, tt := range []struct {
+		name string
+		do   func() error
+	}{
+		{name: "edit by another author", do: func() error { return ticket.EditComment(c.ID, "bob", "theirs") }},
+		{name: "delete by another author", do: func() error { return ticket.DeleteComment(c.ID, "bob") }},
+		{name: "edit missing", do: func() error { return ticket.EditComment(uuid.New(), "alice", "theirs") }},
+		{name: "delete missing", do: func() error { return ticket.DeleteComment(uuid.New(), "alice") }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.do(); err == nil {
+				t.Fatalf("want error")
+			}
+			if len(ticket.Comments) != 1 || ticket.Comments[0] != c {
+				t.Fatalf("want comment unchanged, got %+v", ticket.Comments)
+			}
+		})
+	}
+}
+
+// history returns the events recorded for the ticket, failing the test if it
+// is neither on the board nor finalized.
+func history(t *testing.T, p *Project, id uuid.UUID) []Event {
+	t.Helper()
+	ticket, ok := p.lookup(id)
+	if !ok {
+		t.Fatalf("ticket does not exist: %v", id)
+	}
+	return ticket.History
+}
+
+// kinds returns the kind of each event, in order.
+func kinds(events []Event) string {
+	var s []string
+	for _, e := range events {
+		s = append(s, e.Kind.String())
+	}
+	return strings.Join(s, " ")
+}
+
+// board returns a project with the stages "todo" and "doing", and a ticket
+// assigned to "todo".
+func board(t *testing.T) (*Project, uuid.UUID, uuid.UUID, Ticket) {
+	t.Helper()
+	p := &Project{}
+	todo, doing := p.MakeStage("todo"), p.MakeStage("doing")
+	ticket := Ticket{ID: uuid.New(), Title: "ticket"}
+	if err := p.AssignTicket(todo, ticket); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	ticket, _ = p.FindTicket(ticket.ID)
+	return p, todo, doing, ticket
+}
+
+func TestHistoryCreated(t *testing.T) {
+	p, todo, _, ticket := board(t)
+	events := history(t, p, ticket.ID)
+	if got := kinds(events); got != "created" {
+		t.Fatalf("want created, got %q", got)
+	}
+	if e := events[0]; e.To != todo || e.At.IsZero() {
+		t.Fatalf("want created event into todo with a time, got %+v", e)
+	}
+}
+
+func TestHistoryMoved(t *testing.T) {
+	p, todo, doing, ticket := board(t)
+	if err := p.ProgressTicket(ticket); err != nil {
+		t.Fatalf("progressing ticket: %v", err)
+	}
+	if err := p.MoveTicketToStage(ticket, todo, 0); err != nil {
+		t.Fatalf("moving ticket: %v", err)
+	}
+	// Moving within a stage is not recorded.
+	if err := p.MoveTicketToStage(ticket, todo, 0); err != nil {
+		t.Fatalf("moving ticket: %v", err)
+	}
+	events := history(t, p, ticket.ID)
+	if got := kinds(events); got != "created moved moved" {
+		t.Fatalf("want created moved moved, got %q", got)
+	}
+	if e := events[1]; e.From != todo || e.To != doing {
+		t.Fatalf("want move from todo to doing, got %+v", e)
+	}
+	if e := events[2]; e.From != doing || e.To != todo {
+		t.Fatalf("want move from doing to todo, got %+v", e)
+	}
+}
+
+func TestHistoryEdited(t *testing.T) {
+	p, _, _, ticket := board(t)
+	// Saving without changes is not recorded.
+	if err := p.UpdateTicket(ticket); err != nil {
+		t.Fatalf("updating ticket: %v", err)
+	}
+	edited := ticket.Clone()
+	edited.Title = "renamed"
+	edited.Priority = High
+	edited.Comments = []Comment{{ID: uuid.New(), Text: "not a field"}}
+	if err := p.UpdateTicket(edited); err != nil {
+		t.Fatalf("updating ticket: %v", err)
+	}
+	events := history(t, p, ticket.ID)
+	if got := kinds(events); got != "created edited" {
+		t.Fatalf("want created edited, got %q", got)
+	}
+	want := []Change{
+		{Field: "title", Before: "ticket", After: "renamed"},
+		{Field: "priority", Before: Normal.String(), After: High.String()},
+	}
+	if got := events[1].Changes; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("want changes %+v, got %+v", want, got)
+	}
+}
+
+func TestHistoryFinalized(t *testing.T) {
+	p, todo, _, ticket := board(t)
+	if err := p.FinalizeTicket(ticket); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	events := history(t, p, ticket.ID)
+	if got := kinds(events); got != "created finalized" {
+		t.Fatalf("want created finalized, got %q", got)
+	}
+	if e := events[1]; e.From != todo || !e.At.Equal(p.Finalized[0].Finalized) {
+		t.Fatalf("want finalized event from todo at the time finalized, got %+v", e)
+	}
+}
+
+func TestHistoryRestored(t *testing.T) {
+	p, _, doing, ticket := board(t)
+	if err := p.FinalizeTicket(ticket); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	if err := p.RestoreTicket(ticket, doing); err != nil {
+		t.Fatalf("restoring ticket: %v", err)
+	}
+	events := history(t, p, ticket.ID)
+	if got := kinds(events); got != "created finalized restored" {
+		t.Fatalf("want created finalized restored, got %q", got)
+	}
+	if e := events[2]; e.To != doing {
+		t.Fatalf("want restored event into doing, got %+v", e)
+	}
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"github.com/google/uuid"
+)
+
+// Snapshot counts where the tickets of a project were at the end of a day.
+type Snapshot struct {
+	// Day is the start of the day the snapshot was taken at the end of.
+	Day time.Time
+	// Stages holds the number of tickets in each stage, indexed the same as
+	// the project's stages.
+	Stages []int
+	// Finalized is the number of tickets finalized by the end of the day.
+	Finalized int
+}
+
+// Snapshots derives a snapshot for every day in the window from the history
+// of each ticket, suitable for plotting a cumulative flow diagram.
+//
+// Tickets without history, such as those recorded before history was kept,
+// are counted in their current stage from the time they were created.
+// Tickets in stages that have since been deleted are not counted until they
+// enter a stage that still exists.
+func Snapshots(p *kanban.Project, w Window) []Snapshot {
+	var timelines [][]visit
+	for _, s := range p.Stages {
+		for _, t := range s.Tickets {
+			timelines = append(timelines, timeline(t, s.ID))
+		}
+	}
+	for _, t := range p.Finalized {
+		timelines = append(timelines, timeline(t, uuid.Nil))
+	}
+	var snapshots []Snapshot
+	for day := startOfDay(w.From); day.Before(w.To); day = day.AddDate(0, 0, 1) {
+		var (
+			end  = day.AddDate(0, 0, 1)
+			snap = Snapshot{Day: day, Stages: make([]int, len(p.Stages))}
+		)
+		for _, visits := range timelines {
+			v, ok := at(visits, end)
+			if !ok {
+				continue
+			}
+			if v.finalized {
+				snap.Finalized++
+			} else if ii, ok := p.Stages.Index(v.stage); ok {
+				snap.Stages[ii]++
+			}
+		}
+		snapshots = append(snapshots, snap)
+	}
+	return snapshots
+}
+
+// Week counts the tickets finalized within a week.
+type Week struct {
+	// Start of the week, midnight on Monday.
+	Start time.Time
+	Count int
+}
+
+// Throughput counts the tickets finalized in each week overlapping the
+// window.
+func Throughput(p *kanban.Project, w Window) []Week {
+	var weeks []Week
+	for start := startOfWeek(w.From); start.Before(w.To); start = start.AddDate(0, 0, 7) {
+		week := Week{Start: start}
+		end := start.AddDate(0, 0, 7)
+		for _, t := range p.Finalized {
+			if !t.Finalized.Before(start) && t.Finalized.Before(end) {
+				week.Count++
+			}
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
+
+// visit is a period a ticket spent in a stage, or finalized, starting at a
+// point in time.
+type visit struct {
+	since     time.Time
+	stage     uuid.UUID
+	finalized bool
+}
+
+// timeline reconstructs where a ticket has been from its history, oldest
+// first.
+// current is the stage the ticket is in now, nil if it is finalized.
+func timeline(t kanban.Ticket, current uuid.UUID) []visit {
+	var visits []visit
+	for _, e := range t.History {
+		switch e.Kind {
+		case kanban.Created, kanban.Moved, kanban.Restored:
+			visits = append(visits, visit{since: e.At, stage: e.To})
+		case kanban.Finalized:
+			visits = append(visits, visit{since: e.At, finalized: true})
+		}
+	}
+	if len(visits) > 0 {
+		return visits
+	}
+	if t.Finalized.IsZero() {
+		return []visit{{since: t.Created, stage: current}}
+	}
+	return []visit{{since: t.Finalized, finalized: true}}
+}
+
+// at returns where the ticket was just before time t, such that a ticket
+// created at midnight is not counted in the day before.
+// False means the ticket did not exist yet.
+func at(visits []visit, t time.Time) (visit, bool) {
+	var (
+		last visit
+		ok   bool
+	)
+	for _, v := range visits {
+		if !v.since.Before(t) {
+			break
+		}
+		last, ok = v, true
+	}
+	return last, ok
+}
+
+// startOfDay returns midnight at the start of the day t falls on.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns midnight at the start of the Monday on or before t.
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"github.com/google/uuid"
+)
+
+func TestSnapshots(t *testing.T) {
+	p := project()
+	todo, doing := p.Stages[0].ID, p.Stages[1].ID
+	var (
+		moved = ticket(day.Add(9*time.Hour), todo, doing)
+		later = ticket(day.AddDate(0, 0, 1).Add(10*time.Hour), todo)
+		// Created in a stage that has since been removed, then moved into
+		// todo at midnight.
+		removed = ticket(day.Add(23*time.Hour), uuid.New(), todo)
+		// Recorded before history was kept.
+		untracked = kanban.Ticket{ID: uuid.New(), Title: "untracked", Created: day.AddDate(0, 0, 2)}
+		finalized = finalize(ticket(day, todo), day.AddDate(0, 0, 1).Add(15*time.Hour))
+	)
+	p.Stages[0].Tickets = []kanban.Ticket{later, removed}
+	p.Stages[1].Tickets = []kanban.Ticket{moved, untracked}
+	p.Finalized = []kanban.Ticket{finalized}
+	// The window starts partway through the day before anything happened,
+	// and ends partway through the third day.
+	w := Window{From: day.Add(-12 * time.Hour), To: day.AddDate(0, 0, 2).Add(12 * time.Hour)}
+	snapshots := Snapshots(p, w)
+	want := []struct {
+		day       time.Time
+		stages    []int
+		finalized int
+	}{
+		{day: day.AddDate(0, 0, -1), stages: []int{0, 0, 0}},
+		{day: day, stages: []int{1, 1, 0}},
+		{day: day.AddDate(0, 0, 1), stages: []int{2, 1, 0}, finalized: 1},
+		{day: day.AddDate(0, 0, 2), stages: []int{2, 2, 0}, finalized: 1},
+	}
+	if len(snapshots) != len(want) {
+		t.Fatalf("want %d snapshots, got %d", len(want), len(snapshots))
+	}
+	for ii, want := range want {
+		got := snapshots[ii]
+		if !got.Day.Equal(want.day) {
+			t.Errorf("snapshot %d: want day %v, got %v", ii, want.day, got.Day)
+		}
+		for jj := range want.stages {
+			if got.Stages[jj] != want.stages[jj] {
+				t.Errorf("snapshot %d: want stages %v, got %v", ii, want.stages, got.Stages)
+				break
+			}
+		}
+		if got.Finalized != want.finalized {
+			t.Errorf("snapshot %d: want %d finalized, got %d", ii, want.finalized, got.Finalized)
+		}
+	}
+	if got := Snapshots(p, Window{From: day, To: day}); len(got) != 0 {
+		t.Fatalf("want no snapshots for an empty window, got %d", len(got))
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	p := project()
+	todo := p.Stages[0].ID
+	for _, at := range []time.Time{
+		day,
+		day.AddDate(0, 0, 7).Add(-time.Minute),
+		day.AddDate(0, 0, 7),
+	} {
+		p.Finalized = append(p.Finalized, finalize(ticket(day.AddDate(0, 0, -1), todo), at))
+	}
+	// Starting on a Wednesday, the window still covers the whole of the first
+	// week, and ends partway through the third.
+	weeks := Throughput(p, Window{From: day.AddDate(0, 0, 2), To: day.AddDate(0, 0, 15)})
+	want := []int{2, 1, 0}
+	if len(weeks) != len(want) {
+		t.Fatalf("want %d weeks, got %d", len(want), len(weeks))
+	}
+	for ii, count := range want {
+		if start := day.AddDate(0, 0, 7*ii); !weeks[ii].Start.Equal(start) {
+			t.Errorf("week %d: want start %v, got %v", ii, start, weeks[ii].Start)
+		}
+		if weeks[ii].Count != count {
+			t.Errorf("week %d: want %d finalized, got %d", ii, count, weeks[ii].Count)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package metrics measures the flow of tickets through a Project.
+//
+// Lead time is how long a ticket took from creation to being finalized.
+// Cycle time is how long a ticket took from when work started on it to being
+// finalized, where work is considered started once the ticket first enters a
+// chosen "started" stage, or any stage after it.
+//
+// Both are derived from the history recorded on each ticket, so only
+// finalized tickets are measured.
+//
+// Daily snapshots of how many tickets sat in each stage, and the number of
+// tickets finalized each week, are derived from the same history for
+// charting the flow of work over time.
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"github.com/google/uuid"
+)
+
+// Window is the span of time, [From, To), that tickets are finalized within.
+type Window struct {
+	From time.Time
+	To   time.Time
+}
+
+// LastDays returns the window covering the given number of days up to now.
+func LastDays(now time.Time, days int) Window {
+	return Window{
+		From: now.AddDate(0, 0, -days),
+		To:   now,
+	}
+}
+
+// Contains reports whether t falls within the window.
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.From) && t.Before(w.To)
+}
+
+// Times holds the measurements of a single finalized ticket.
+type Times struct {
+	Ticket kanban.Ticket
+	Lead   time.Duration
+	Cycle  time.Duration
+	// Started is false if the ticket was never seen in the started stage or
+	// beyond, in which case Cycle is zero.
+	Started bool
+}
+
+// Percentiles summarises a set of durations.
+type Percentiles struct {
+	// N is the number of durations summarised.
+	N   int
+	P50 time.Duration
+	P85 time.Duration
+	P95 time.Duration
+}
+
+// Report holds the measurements of a project over a window.
+type Report struct {
+	Window Window
+	// Tickets holds the times for each ticket finalized within the window,
+	// in the order they were finalized.
+	Tickets []Times
+	Lead    Percentiles
+	// Cycle summarises only the tickets that were started.
+	Cycle Percentiles
+}
+
+// Measure the tickets of the project finalized within the window, treating
+// work as started once a ticket enters the started stage.
+func Measure(p *kanban.Project, started uuid.UUID, w Window) Report {
+	r := Report{Window: w}
+	var lead, cycle []time.Duration
+	for _, t := range p.Finalized {
+		if !w.Contains(t.Finalized) {
+			continue
+		}
+		times := Ticket(p, t, started)
+		r.Tickets = append(r.Tickets, times)
+		lead = append(lead, times.Lead)
+		if times.Started {
+			cycle = append(cycle, times.Cycle)
+		}
+	}
+	sort.SliceStable(r.Tickets, func(ii, jj int) bool {
+		return r.Tickets[ii].Ticket.Finalized.Before(r.Tickets[jj].Ticket.Finalized)
+	})
+	r.Lead = Summarize(lead)
+	r.Cycle = Summarize(cycle)
+	return r
+}
+
+// Ticket measures a single finalized ticket.
+// Unfinalized tickets measure as zero.
+func Ticket(p *kanban.Project, t kanban.Ticket, started uuid.UUID) Times {
+	times := Times{Ticket: t}
+	if t.Finalized.IsZero() {
+		return times
+	}
+	times.Lead = t.Finalized.Sub(t.Created)
+	if start, ok := Started(p, t, started); ok {
+		times.Cycle = t.Finalized.Sub(start)
+		times.Started = true
+	}
+	return times
+}
+
+// Started returns when the ticket first entered the started stage, or any
+// stage after it.
+// Stages that have since been deleted are not considered.
+func Started(p *kanban.Project, t kanban.Ticket, started uuid.UUID) (time.Time, bool) {
+	from, ok := p.Stages.Index(started)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, e := range t.History {
+		if e.Kind != kanban.Created && e.Kind != kanban.Moved && e.Kind != kanban.Restored {
+			continue
+		}
+		if ii, ok := p.Stages.Index(e.To); ok && ii >= from {
+			return e.At, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Summarize computes the percentiles of the durations.
+func Summarize(durations []time.Duration) Percentiles {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(ii, jj int) bool {
+		return sorted[ii] < sorted[jj]
+	})
+	return Percentiles{
+		N:   len(sorted),
+		P50: Percentile(sorted, 50),
+		P85: Percentile(sorted, 85),
+		P95: Percentile(sorted, 95),
+	}
+}
+
+// Percentile returns the pth percentile of the sorted durations using the
+// nearest-rank method.
+// Returns zero for no durations.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"github.com/google/uuid"
+)
+
+// day is midnight on the first day of the test window.
+var day = time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+// project returns a project with the stages "todo", "doing" and "done".
+func project() *kanban.Project {
+	p := &kanban.Project{ID: uuid.New(), Name: "project"}
+	p.MakeStage("todo")
+	p.MakeStage("doing")
+	p.MakeStage("done")
+	return p
+}
+
+// ticket returns a ticket created at the given time, followed by a move into
+// each of the given stages an hour apart.
+func ticket(created time.Time, stages ...uuid.UUID) kanban.Ticket {
+	t := kanban.Ticket{ID: uuid.New(), Title: "ticket", Created: created}
+	for ii, stage := range stages {
+		kind := kanban.Moved
+		if ii == 0 {
+			kind = kanban.Created
+		}
+		t.History = append(t.History, kanban.Event{
+			Kind: kind,
+			At:   created.Add(time.Duration(ii) * time.Hour),
+			To:   stage,
+		})
+	}
+	return t
+}
+
+// finalize the ticket at the given time.
+func finalize(t kanban.Ticket, at time.Time) kanban.Ticket {
+	t.Finalized = at
+	t.History = append(t.History, kanban.Event{Kind: kanban.Finalized, At: at})
+	return t
+}
+
+func TestPercentile(t *testing.T) {
+	ten := make([]time.Duration, 10)
+	for ii := range ten {
+		ten[ii] = time.Duration(ii+1) * time.Hour
+	}
+	for _, tt := range []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{name: "empty", sorted: nil, p: 50, want: 0},
+		{name: "single sample", sorted: []time.Duration{time.Hour}, p: 95, want: time.Hour},
+		{name: "zeroth", sorted: ten, p: 0, want: time.Hour},
+		{name: "median", sorted: ten, p: 50, want: 5 * time.Hour},
+		// Between ranks the nearest rank above is taken, rather than
+		// interpolating: 85% of 10 is rank 8.5, so rank 9.
+		{name: "between ranks", sorted: ten, p: 85, want: 9 * time.Hour},
+		{name: "top", sorted: ten, p: 100, want: 10 * time.Hour},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	if got := Summarize(nil); got != (Percentiles{}) {
+		t.Fatalf("want zero percentiles for no durations, got %+v", got)
+	}
+	got := Summarize([]time.Duration{3 * time.Hour, time.Hour, 2 * time.Hour})
+	want := Percentiles{N: 3, P50: 2 * time.Hour, P85: 3 * time.Hour, P95: 3 * time.Hour}
+	if got != want {
+		t.Fatalf("want durations sorted before summarising %+v, got %+v", want, got)
+	}
+}
+
+func TestStarted(t *testing.T) {
+	p := project()
+	todo, doing, done := p.Stages[0].ID, p.Stages[1].ID, p.Stages[2].ID
+	for _, tt := range []struct {
+		name    string
+		ticket  kanban.Ticket
+		started uuid.UUID
+		want    time.Time
+		ok      bool
+	}{
+		{
+			name:    "entered the started stage",
+			ticket:  ticket(day, todo, doing, done),
+			started: doing,
+			want:    day.Add(time.Hour),
+			ok:      true,
+		},
+		{
+			name:    "skipped past the started stage",
+			ticket:  ticket(day, todo, done),
+			started: doing,
+			want:    day.Add(time.Hour),
+			ok:      true,
+		},
+		{
+			name:    "created in the started stage",
+			ticket:  ticket(day, doing),
+			started: doing,
+			want:    day,
+			ok:      true,
+		},
+		{
+			name:    "never reached the started stage",
+			ticket:  ticket(day, todo),
+			started: doing,
+		},
+		{
+			name:    "started stage removed",
+			ticket:  ticket(day, todo, doing),
+			started: uuid.New(),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Started(p, tt.ticket, tt.started)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("want %v, %v, got %v, %v", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestTicket(t *testing.T) {
+	p := project()
+	todo, doing := p.Stages[0].ID, p.Stages[1].ID
+	open := ticket(day, todo, doing)
+	if got := Ticket(p, open, doing); got.Lead != 0 || got.Cycle != 0 || got.Started {
+		t.Fatalf("want unfinalized ticket measured as zero, got %+v", got)
+	}
+	got := Ticket(p, finalize(open, day.Add(3*time.Hour)), doing)
+	if got.Lead != 3*time.Hour || got.Cycle != 2*time.Hour || !got.Started {
+		t.Fatalf("want lead 3h and cycle 2h, got %v and %v", got.Lead, got.Cycle)
+	}
+	got = Ticket(p, finalize(ticket(day, todo), day.Add(time.Hour)), doing)
+	if got.Lead != time.Hour || got.Cycle != 0 || got.Started {
+		t.Fatalf("want ticket never started measured by lead time only, got %+v", got)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	if r := Measure(project(), uuid.New(), LastDays(day, 7)); len(r.Tickets) != 0 || r.Lead.N != 0 {
+		t.Fatalf("want empty report for a project without tickets, got %+v", r)
+	}
+	p := project()
+	todo, doing := p.Stages[0].ID, p.Stages[1].ID
+	w := Window{From: day, To: day.AddDate(0, 0, 7)}
+	var (
+		later     = finalize(ticket(day, todo, doing), day.Add(5*time.Hour))
+		sooner    = finalize(ticket(day, todo, doing), day.Add(3*time.Hour))
+		unstarted = finalize(ticket(day, todo), day.Add(2*time.Hour))
+		before    = finalize(ticket(day.AddDate(0, 0, -2), todo, doing), day.Add(-time.Hour))
+		at        = finalize(ticket(day, todo, doing), w.To)
+	)
+	p.Finalized = []kanban.Ticket{later, sooner, unstarted, before, at}
+	// Tickets still on the board are not measured.
+	if err := p.AssignTicket(doing, ticket(day, todo, doing)); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	r := Measure(p, doing, w)
+	if len(r.Tickets) != 3 {
+		t.Fatalf("want 3 tickets finalized within the window, got %d", len(r.Tickets))
+	}
+	for ii, want := range []kanban.Ticket{unstarted, sooner, later} {
+		if r.Tickets[ii].Ticket.ID != want.ID {
+			t.Fatalf("want tickets in the order they were finalized")
+		}
+	}
+	if r.Lead.N != 3 || r.Lead.P50 != 3*time.Hour {
+		t.Fatalf("want median lead time of 3h over 3 tickets, got %+v", r.Lead)
+	}
+	if r.Cycle.N != 2 || r.Cycle.P50 != 2*time.Hour || r.Cycle.P95 != 4*time.Hour {
+		t.Fatalf("want cycle times of the 2 started tickets, got %+v", r.Cycle)
+	}
+}
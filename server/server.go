@@ -0,0 +1,516 @@
+// Package server exposes projects, stages and tickets as a REST API with JSON
+// bodies.
+//
+// Every call goes through a storage.Storer, using the same kanban.Project
+// methods as the app, so the rules of a project, such as stage limits, apply
+// to API clients too.
+//
+// Routes:
+//
+//	GET    /projects                                  list active projects
+//	POST   /projects                                  create a project
+//	GET    /projects/{project}                        get a project
+//	PUT    /projects/{project}                        replace a project
+//	DELETE /projects/{project}                        delete a project
+//	POST   /projects/{project}/archive                archive a project
+//	POST   /projects/{project}/restore                restore an archived project
+//	GET    /archived                                  list archived projects
+//	GET    /projects/{project}/stages                 list the stages of a project
+//	POST   /projects/{project}/stages/{stage}/tickets assign a new ticket to a stage
+//	GET    /projects/{project}/tickets/{ticket}       get a ticket
+//	PUT    /projects/{project}/tickets/{ticket}       update a ticket
+//	POST   /projects/{project}/tickets/{ticket}/progress
+//	POST   /projects/{project}/tickets/{ticket}/regress
+//	POST   /projects/{project}/tickets/{ticket}/finalize
+//	POST   /projects/{project}/tickets/{ticket}/comments
+//
+// Errors are returned as {"error": "..."} with a status code describing the
+// kind of failure: 404 for missing entities, 400 for malformed requests, 409
+// when a project's rules refuse the change and 412 when a project is replaced
+// from an out of date revision.
+//
+// Updating a ticket takes only the fields a person edits, such as its title
+// and blockers. Its identity, history and comments are kept as they are.
+// Comments are added with their own route, which takes {"Text": "..."} and
+// credits the person named by the Kanban-User header.
+//
+// Every change advances the revision of the project, as tracked by storage.
+// Replacing a project requires the revision it was loaded at, so that clients
+// cannot overwrite changes they have not seen.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/storage"
+	"github.com/google/uuid"
+)
+
+// UserHeader names the person making a request, such as the author of a
+// comment.
+const UserHeader = "Kanban-User"
+
+// Server handles API requests against a Storer.
+type Server struct {
+	Storage storage.Storer
+	// mu serializes requests, since Storers are not safe for concurrent use.
+	mu sync.Mutex
+}
+
+// New creates a server for the given storage.
+func New(s storage.Storer) *Server {
+	return &Server{Storage: s}
+}
+
+// NotFoundError is returned when an entity does not exist.
+type NotFoundError struct {
+	Kind string
+	ID   string
+}
+
+func (err NotFoundError) Error() string {
+	return fmt.Sprintf("%s does not exist: %s", err.Kind, err.ID)
+}
+
+// BadRequestError is returned when a request cannot be understood.
+type BadRequestError struct {
+	Err error
+}
+
+func (err BadRequestError) Error() string {
+	return fmt.Sprintf("bad request: %v", err.Err)
+}
+
+func (err BadRequestError) Unwrap() error {
+	return err.Err
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, status, err := s.route(r)
+	if err != nil {
+		status = statusOf(err)
+		if status == http.StatusInternalServerError {
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		}
+		v = map[string]string{"error": err.Error()}
+	}
+	if v == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("%s %s: encoding response: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// statusOf maps an error to the HTTP status that describes it.
+func statusOf(err error) int {
+	var (
+		notFound   NotFoundError
+		badRequest BadRequestError
+		limit      kanban.LimitError
+		blocked    kanban.BlockedError
+		cycle      kanban.CycleError
+		checklist  kanban.ChecklistError
+	)
+	switch {
+	case errors.Is(err, storage.ErrConflict):
+		return http.StatusPreconditionFailed
+	case errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &badRequest):
+		return http.StatusBadRequest
+	case errors.As(err, &limit), errors.As(err, &blocked), errors.As(err, &cycle), errors.As(err, &checklist):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// route the request to its handler, returning the value to respond with and
+// the status to respond with on success.
+func (s *Server) route(r *http.Request) (interface{}, int, error) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	method := r.Method
+	switch {
+	case match(path, "archived") && method == http.MethodGet:
+		projects, err := s.Storage.ListArchived()
+		return nonNil(projects), http.StatusOK, err
+	case match(path, "projects") && method == http.MethodGet:
+		projects, err := s.Storage.List()
+		return nonNil(projects), http.StatusOK, err
+	case match(path, "projects") && method == http.MethodPost:
+		p, err := s.createProject(r)
+		return p, http.StatusCreated, err
+	case len(path) < 2 || path[0] != "projects":
+		return nil, 0, NotFoundError{Kind: "route", ID: r.URL.Path}
+	}
+	id, err := parseID("project", path[1])
+	if err != nil {
+		return nil, 0, err
+	}
+	switch rest := path[2:]; {
+	case match(rest) && method == http.MethodGet:
+		p, err := s.find(id)
+		return p, http.StatusOK, err
+	case match(rest) && method == http.MethodPut:
+		p, err := s.updateProject(id, r)
+		return p, http.StatusOK, err
+	case match(rest) && method == http.MethodDelete:
+		if err := s.exists(id); err != nil {
+			return nil, 0, err
+		}
+		return nil, http.StatusNoContent, s.Storage.Delete(id)
+	case match(rest, "archive") && method == http.MethodPost:
+		if _, err := s.find(id); err != nil {
+			return nil, 0, err
+		}
+		return nil, http.StatusNoContent, s.Storage.Archive(id)
+	case match(rest, "restore") && method == http.MethodPost:
+		if err := s.findArchived(id); err != nil {
+			return nil, 0, err
+		}
+		return nil, http.StatusNoContent, s.Storage.Restore(id)
+	case match(rest, "stages") && method == http.MethodGet:
+		p, err := s.find(id)
+		return p.Stages, http.StatusOK, err
+	case match(rest, "stages", "*", "tickets") && method == http.MethodPost:
+		stage, err := parseID("stage", rest[1])
+		if err != nil {
+			return nil, 0, err
+		}
+		t, err := s.assignTicket(id, stage, r)
+		return t, http.StatusCreated, err
+	case len(rest) >= 2 && rest[0] == "tickets":
+		ticket, err := parseID("ticket", rest[1])
+		if err != nil {
+			return nil, 0, err
+		}
+		return s.routeTicket(id, ticket, rest[2:], r)
+	}
+	return nil, 0, NotFoundError{Kind: "route", ID: method + " " + r.URL.Path}
+}
+
+// routeTicket routes requests for a single ticket.
+func (s *Server) routeTicket(project, ticket uuid.UUID, rest []string, r *http.Request) (interface{}, int, error) {
+	method := r.Method
+	switch {
+	case match(rest) && method == http.MethodGet:
+		p, err := s.find(project)
+		if err != nil {
+			return nil, 0, err
+		}
+		t, err := findTicket(&p, ticket)
+		return t, http.StatusOK, err
+	case match(rest) && method == http.MethodPut:
+		t, err := s.updateTicket(project, ticket, r)
+		return t, http.StatusOK, err
+	case match(rest, "progress") && method == http.MethodPost:
+		t, err := s.moveTicket(project, ticket, (*kanban.Project).ProgressTicket)
+		return t, http.StatusOK, err
+	case match(rest, "regress") && method == http.MethodPost:
+		t, err := s.moveTicket(project, ticket, (*kanban.Project).RegressTicket)
+		return t, http.StatusOK, err
+	case match(rest, "finalize") && method == http.MethodPost:
+		t, err := s.finalizeTicket(project, ticket)
+		return t, http.StatusOK, err
+	case match(rest, "comments") && method == http.MethodPost:
+		c, err := s.addComment(project, ticket, r)
+		return c, http.StatusCreated, err
+	}
+	return nil, 0, NotFoundError{Kind: "route", ID: method + " " + r.URL.Path}
+}
+
+func (s *Server) createProject(r *http.Request) (kanban.Project, error) {
+	var p kanban.Project
+	if err := decode(r, &p); err != nil {
+		return p, err
+	}
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	for ii := range p.Stages {
+		if p.Stages[ii].ID == uuid.Nil {
+			p.Stages[ii].ID = uuid.New()
+		}
+	}
+	if err := s.Storage.Create(p); err != nil {
+		return p, err
+	}
+	return s.find(p.ID)
+}
+
+func (s *Server) updateProject(id uuid.UUID, r *http.Request) (kanban.Project, error) {
+	if _, err := s.find(id); err != nil {
+		return kanban.Project{}, err
+	}
+	var p kanban.Project
+	if err := decode(r, &p); err != nil {
+		return p, err
+	}
+	p.ID = id
+	if err := s.save(&p); err != nil {
+		return p, err
+	}
+	return s.find(id)
+}
+
+func (s *Server) assignTicket(project, stage uuid.UUID, r *http.Request) (kanban.Ticket, error) {
+	var t kanban.Ticket
+	if err := decode(r, &t); err != nil {
+		return t, err
+	}
+	p, err := s.find(project)
+	if err != nil {
+		return t, err
+	}
+	if _, ok := p.Stages.Index(stage); !ok {
+		return t, NotFoundError{Kind: "stage", ID: stage.String()}
+	}
+	// The server owns identity, history and comments, so that clients cannot
+	// forge them.
+	t.ID = uuid.New()
+	t.Created = time.Now()
+	t.Finalized = time.Time{}
+	t.History = nil
+	t.Comments = nil
+	if err := p.AssignTicket(stage, t); err != nil {
+		return t, invalid(err)
+	}
+	if err := s.save(&p); err != nil {
+		return t, err
+	}
+	t, _ = p.FindTicket(t.ID)
+	return t, nil
+}
+
+func (s *Server) updateTicket(project, ticket uuid.UUID, r *http.Request) (kanban.Ticket, error) {
+	var edit kanban.Ticket
+	if err := decode(r, &edit); err != nil {
+		return edit, err
+	}
+	p, err := s.find(project)
+	if err != nil {
+		return edit, err
+	}
+	old, err := findTicket(&p, ticket)
+	if err != nil {
+		return edit, err
+	}
+	// Only the editable fields are taken from the client. Identity, history
+	// and comments are kept as the server has them, so that clients cannot
+	// forge them.
+	t := old.Clone()
+	t.Title = edit.Title
+	t.Summary = edit.Summary
+	t.Details = edit.Details
+	t.Labels = edit.Labels
+	t.Priority = edit.Priority
+	t.Due = edit.Due
+	t.Assignees = edit.Assignees
+	t.Checklist = edit.Checklist
+	t.BlockedBy = edit.BlockedBy
+	if err := p.UpdateTicket(t); err != nil {
+		return t, invalid(err)
+	}
+	if err := s.save(&p); err != nil {
+		return t, err
+	}
+	t, _ = p.FindTicket(t.ID)
+	return t, nil
+}
+
+// addComment adds a comment to a ticket, written by the person named in the
+// request's UserHeader.
+func (s *Server) addComment(project, ticket uuid.UUID, r *http.Request) (kanban.Comment, error) {
+	author := r.Header.Get(UserHeader)
+	if author == "" {
+		return kanban.Comment{}, BadRequestError{Err: fmt.Errorf("missing %s header naming the author", UserHeader)}
+	}
+	var body struct {
+		Text string
+	}
+	if err := decode(r, &body); err != nil {
+		return kanban.Comment{}, err
+	}
+	if strings.TrimSpace(body.Text) == "" {
+		return kanban.Comment{}, BadRequestError{Err: errors.New("comment has no text")}
+	}
+	p, err := s.find(project)
+	if err != nil {
+		return kanban.Comment{}, err
+	}
+	t, err := findTicket(&p, ticket)
+	if err != nil {
+		return kanban.Comment{}, err
+	}
+	c := t.AddComment(author, body.Text)
+	if err := p.UpdateTicket(t); err != nil {
+		return c, err
+	}
+	return c, s.save(&p)
+}
+
+func (s *Server) moveTicket(project, ticket uuid.UUID, move func(*kanban.Project, kanban.Ticket) error) (kanban.Ticket, error) {
+	p, err := s.find(project)
+	if err != nil {
+		return kanban.Ticket{}, err
+	}
+	t, err := findTicket(&p, ticket)
+	if err != nil {
+		return t, err
+	}
+	if err := move(&p, t); err != nil {
+		return t, err
+	}
+	if err := s.save(&p); err != nil {
+		return t, err
+	}
+	t, _ = p.FindTicket(t.ID)
+	return t, nil
+}
+
+func (s *Server) finalizeTicket(project, ticket uuid.UUID) (kanban.Ticket, error) {
+	p, err := s.find(project)
+	if err != nil {
+		return kanban.Ticket{}, err
+	}
+	t, err := findTicket(&p, ticket)
+	if err != nil {
+		return t, err
+	}
+	if err := p.FinalizeTicket(t); err != nil {
+		return t, err
+	}
+	if err := s.save(&p); err != nil {
+		return t, err
+	}
+	for _, finalized := range p.Finalized {
+		if finalized.ID == t.ID {
+			t = finalized
+		}
+	}
+	return t, nil
+}
+
+// save a changed project.
+// Storage checks and advances the revision of the project.
+func (s *Server) save(p *kanban.Project) error {
+	saved := []kanban.Project{*p}
+	if err := s.Storage.Save(saved...); err != nil {
+		return err
+	}
+	p.Revision = saved[0].Revision
+	return nil
+}
+
+// find an active project.
+func (s *Server) find(id uuid.UUID) (kanban.Project, error) {
+	p, ok, err := s.Storage.Find(id)
+	if err != nil {
+		return p, err
+	}
+	if !ok {
+		return p, NotFoundError{Kind: "project", ID: id.String()}
+	}
+	return p, nil
+}
+
+// findArchived returns a NotFoundError if the project is not archived.
+func (s *Server) findArchived(id uuid.UUID) error {
+	archived, err := s.Storage.ListArchived()
+	if err != nil {
+		return err
+	}
+	for _, p := range archived {
+		if p.ID == id {
+			return nil
+		}
+	}
+	return NotFoundError{Kind: "archived project", ID: id.String()}
+}
+
+// exists returns a NotFoundError unless the project is either active or
+// archived.
+func (s *Server) exists(id uuid.UUID) error {
+	var notFound NotFoundError
+	if _, err := s.find(id); !errors.As(err, &notFound) {
+		return err
+	}
+	if err := s.findArchived(id); !errors.As(err, &notFound) {
+		return err
+	}
+	return NotFoundError{Kind: "project", ID: id.String()}
+}
+
+// invalid reports an error from applying a client's ticket to a project as a
+// BadRequestError, such as for a blocker that does not exist, unless it is one
+// of the project's rules refusing the change.
+func invalid(err error) error {
+	if statusOf(err) == http.StatusInternalServerError {
+		return BadRequestError{Err: err}
+	}
+	return err
+}
+
+// findTicket finds a ticket on the board of a project.
+func findTicket(p *kanban.Project, id uuid.UUID) (kanban.Ticket, error) {
+	t, ok := p.FindTicket(id)
+	if !ok {
+		return t, NotFoundError{Kind: "ticket", ID: id.String()}
+	}
+	return t, nil
+}
+
+// match reports whether the path consists of exactly the given segments,
+// where "*" matches any segment.
+func match(path []string, segments ...string) bool {
+	if len(path) == 1 && path[0] == "" {
+		path = nil
+	}
+	if len(path) != len(segments) {
+		return false
+	}
+	for ii := range segments {
+		if segments[ii] != "*" && segments[ii] != path[ii] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseID parses the ID of the given kind of entity from a path segment.
+func parseID(kind, segment string) (uuid.UUID, error) {
+	id, err := uuid.Parse(segment)
+	if err != nil {
+		return id, BadRequestError{Err: fmt.Errorf("%s ID: %w", kind, err)}
+	}
+	return id, nil
+}
+
+// decode the JSON body of a request into v.
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return BadRequestError{Err: fmt.Errorf("decoding body: %w", err)}
+	}
+	return nil
+}
+
+// nonNil ensures an empty list encodes as [] rather than null.
+func nonNil(projects []kanban.Project) []kanban.Project {
+	if projects == nil {
+		return []kanban.Project{}
+	}
+	return projects
+}
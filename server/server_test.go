@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/storage/mem"
+	"github.com/google/uuid"
+)
+
+// call the server with a request, encoding body as JSON if not nil and
+// decoding the response into v if not nil.
+// Returns the status of the response.
+func call(t *testing.T, s *Server, method, path string, body, v interface{}) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encoding request: %v", err)
+		}
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(method, path, &buf))
+	if v != nil && w.Code < 300 {
+		if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+	return w.Code
+}
+
+// setup creates a server holding a project with the stages "todo" and "done".
+func setup(t *testing.T) (*Server, kanban.Project) {
+	t.Helper()
+	s := New(mem.New())
+	var p kanban.Project
+	draft := kanban.Project{Name: "project"}
+	draft.MakeStage("todo")
+	draft.MakeStage("done")
+	if status := call(t, s, http.MethodPost, "/projects", draft, &p); status != http.StatusCreated {
+		t.Fatalf("creating project: status %d", status)
+	}
+	return s, p
+}
+
+// assign a new ticket to the stage at index ii of the project.
+func assign(t *testing.T, s *Server, p kanban.Project, ii int, ticket kanban.Ticket) kanban.Ticket {
+	t.Helper()
+	path := "/projects/" + p.ID.String() + "/stages/" + p.Stages[ii].ID.String() + "/tickets"
+	if status := call(t, s, http.MethodPost, path, ticket, &ticket); status != http.StatusCreated {
+		t.Fatalf("assigning ticket: status %d", status)
+	}
+	return ticket
+}
+
+func TestProjects(t *testing.T) {
+	s, p := setup(t)
+	if p.ID == uuid.Nil || len(p.Stages) != 2 || p.Stages[0].ID == uuid.Nil {
+		t.Fatalf("want created project with identified stages, got %+v", p)
+	}
+	var list []kanban.Project
+	if status := call(t, s, http.MethodGet, "/projects", nil, &list); status != http.StatusOK || len(list) != 1 {
+		t.Fatalf("want 1 listed project, got %d with status %d", len(list), status)
+	}
+	var got kanban.Project
+	if status := call(t, s, http.MethodGet, "/projects/"+p.ID.String(), nil, &got); status != http.StatusOK || got.Name != "project" {
+		t.Fatalf("want project, got %q with status %d", got.Name, status)
+	}
+	p.Name = "renamed"
+	if status := call(t, s, http.MethodPut, "/projects/"+p.ID.String(), p, &got); status != http.StatusOK {
+		t.Fatalf("updating project: status %d", status)
+	}
+	if got.Name != "renamed" || got.Revision != p.Revision+1 {
+		t.Fatalf("want renamed project at the next revision, got %q at %d", got.Name, got.Revision)
+	}
+}
+
+func TestTickets(t *testing.T) {
+	s, p := setup(t)
+	ticket := assign(t, s, p, 0, kanban.Ticket{Title: "ticket"})
+	path := "/projects/" + p.ID.String() + "/tickets/" + ticket.ID.String()
+	var got kanban.Ticket
+	if status := call(t, s, http.MethodGet, path, nil, &got); status != http.StatusOK || got.Title != "ticket" {
+		t.Fatalf("want ticket, got %q with status %d", got.Title, status)
+	}
+	edit := got
+	edit.Title = "edited"
+	edit.History = nil
+	edit.Created = time.Time{}
+	if status := call(t, s, http.MethodPut, path, edit, &got); status != http.StatusOK {
+		t.Fatalf("updating ticket: status %d", status)
+	}
+	if got.Title != "edited" || !got.Created.Equal(ticket.Created) || len(got.History) != 2 {
+		t.Fatalf("want edited title with history kept, got %q with %d events", got.Title, len(got.History))
+	}
+	if status := call(t, s, http.MethodPost, path+"/progress", nil, &got); status != http.StatusOK {
+		t.Fatalf("progressing ticket: status %d", status)
+	}
+	var stages kanban.Stages
+	call(t, s, http.MethodGet, "/projects/"+p.ID.String()+"/stages", nil, &stages)
+	if !stages[1].Contains(ticket) {
+		t.Fatalf("want ticket progressed into done")
+	}
+	if status := call(t, s, http.MethodPost, path+"/finalize", nil, &got); status != http.StatusOK {
+		t.Fatalf("finalizing ticket: status %d", status)
+	}
+	if got.Finalized.IsZero() {
+		t.Fatalf("want finalized ticket")
+	}
+	if status := call(t, s, http.MethodGet, path, nil, nil); status != http.StatusNotFound {
+		t.Fatalf("want finalized ticket off the board, got status %d", status)
+	}
+}
+
+func TestBlockerCycle(t *testing.T) {
+	s, p := setup(t)
+	a := assign(t, s, p, 0, kanban.Ticket{Title: "a"})
+	b := assign(t, s, p, 0, kanban.Ticket{Title: "b", BlockedBy: []uuid.UUID{a.ID}})
+	a.BlockedBy = []uuid.UUID{b.ID}
+	path := "/projects/" + p.ID.String() + "/tickets/" + a.ID.String()
+	if status := call(t, s, http.MethodPut, path, a, nil); status != http.StatusConflict {
+		t.Fatalf("want cycle refused with status %d, got %d", http.StatusConflict, status)
+	}
+	a.BlockedBy = []uuid.UUID{uuid.New()}
+	if status := call(t, s, http.MethodPut, path, a, nil); status != http.StatusBadRequest {
+		t.Fatalf("want unknown blocker refused with status %d, got %d", http.StatusBadRequest, status)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	s, p := setup(t)
+	missing := uuid.New().String()
+	for _, path := range []string{
+		"/projects/" + missing,
+		"/projects/" + p.ID.String() + "/tickets/" + missing,
+		"/unknown",
+	} {
+		if status := call(t, s, http.MethodGet, path, nil, nil); status != http.StatusNotFound {
+			t.Errorf("GET %s: want status %d, got %d", path, http.StatusNotFound, status)
+		}
+	}
+	if status := call(t, s, http.MethodDelete, "/projects/"+missing, nil, nil); status != http.StatusNotFound {
+		t.Errorf("DELETE missing project: want status %d, got %d", http.StatusNotFound, status)
+	}
+	if status := call(t, s, http.MethodGet, "/projects/not-an-id", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("want malformed ID refused with status %d, got %d", http.StatusBadRequest, status)
+	}
+	// Saving from the revision the project was created at succeeds once, and
+	// then that revision is out of date.
+	path := "/projects/" + p.ID.String()
+	if status := call(t, s, http.MethodPut, path, p, nil); status != http.StatusOK {
+		t.Fatalf("updating project: status %d", status)
+	}
+	if status := call(t, s, http.MethodPut, path, p, nil); status != http.StatusPreconditionFailed {
+		t.Fatalf("want stale revision refused with status %d, got %d", http.StatusPreconditionFailed, status)
+	}
+}
+
+func TestDeleteProject(t *testing.T) {
+	s, p := setup(t)
+	path := "/projects/" + p.ID.String()
+	if status := call(t, s, http.MethodPost, path+"/archive", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("archiving project: status %d", status)
+	}
+	// Archived projects can be deleted too.
+	if status := call(t, s, http.MethodDelete, path, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("deleting archived project: status %d", status)
+	}
+	if status := call(t, s, http.MethodDelete, path, nil, nil); status != http.StatusNotFound {
+		t.Fatalf("want deleted project not found, got status %d", status)
+	}
+}
+
+func TestComments(t *testing.T) {
+	s, p := setup(t)
+	// Comments cannot be forged when creating a ticket.
+	ticket := assign(t, s, p, 0, kanban.Ticket{
+		Title:    "ticket",
+		Comments: []kanban.Comment{{ID: uuid.New(), Author: "someone else", Text: "forged"}},
+	})
+	if len(ticket.Comments) != 0 {
+		t.Fatalf("want comments dropped from a new ticket, got %d", len(ticket.Comments))
+	}
+	path := "/projects/" + p.ID.String() + "/tickets/" + ticket.ID.String()
+	comment := func(user, text string) (int, kanban.Comment) {
+		var (
+			c   kanban.Comment
+			w   = httptest.NewRecorder()
+			req = httptest.NewRequest(http.MethodPost, path+"/comments", strings.NewReader(`{"Text": "`+text+`"}`))
+		)
+		if user != "" {
+			req.Header.Set(UserHeader, user)
+		}
+		s.ServeHTTP(w, req)
+		if w.Code == http.StatusCreated {
+			if err := json.NewDecoder(w.Body).Decode(&c); err != nil {
+				t.Fatalf("decoding comment: %v", err)
+			}
+		}
+		return w.Code, c
+	}
+	if status, _ := comment("", "anonymous"); status != http.StatusBadRequest {
+		t.Fatalf("want comment without an author refused with status %d, got %d", http.StatusBadRequest, status)
+	}
+	if status, _ := comment("ada", " "); status != http.StatusBadRequest {
+		t.Fatalf("want empty comment refused with status %d, got %d", http.StatusBadRequest, status)
+	}
+	status, c := comment("ada", "looks good")
+	if status != http.StatusCreated || c.Author != "ada" || c.Created.IsZero() {
+		t.Fatalf("want comment credited to ada, got %+v with status %d", c, status)
+	}
+	call(t, s, http.MethodGet, path, nil, &ticket)
+	if len(ticket.Comments) != 1 || ticket.Comments[0].ID != c.ID {
+		t.Fatalf("want comment on the ticket, got %d comments", len(ticket.Comments))
+	}
+}
@@ -2,7 +2,9 @@ package bolt
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"git.sr.ht/~jackmordaunt/kanban"
 	"git.sr.ht/~jackmordaunt/kanban/storage"
@@ -27,8 +29,17 @@ var (
 	BucketArchive Bucket = Bucket("Archive")
 )
 
+// ErrLocked is returned by Open when another process, such as a running
+// instance of the app, holds the database file open.
+var ErrLocked = errors.New("database file is in use by another process")
+
 func Open(path string) (*Storer, error) {
-	db, err := bolt.Open(path, 0660, nil)
+	// Bolt locks the file for the lifetime of the process, so give up rather
+	// than waiting forever on another process.
+	db, err := bolt.Open(path, 0660, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, ErrLocked
+	}
 	if err != nil {
 		return nil, fmt.Errorf("opening database file: %w", err)
 	}
@@ -43,7 +54,55 @@ func Open(path string) (*Storer, error) {
 	}); err != nil {
 		return nil, fmt.Errorf("initializing buckets: %w", err)
 	}
-	return &Storer{DB: db}, nil
+	s := &Storer{DB: db}
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("migrating data: %w", err)
+	}
+	return s, nil
+}
+
+// migrate upgrades projects written by older versions, rewriting them in the
+// current format.
+//
+// Stages were originally identified by name and stored without an ID, so each
+// one is given a stable ID the first time it is loaded.
+func (db *Storer) migrate() error {
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range []Bucket{BucketProject, BucketArchive} {
+			b := tx.Bucket(bucket)
+			updates := make(map[string][]byte)
+			if err := b.ForEach(func(k, v []byte) error {
+				var p kanban.Project
+				if err := json.Unmarshal(v, &p); err != nil {
+					return fmt.Errorf("deserializing project: %w", err)
+				}
+				var changed bool
+				for ii := range p.Stages {
+					if p.Stages[ii].ID == uuid.Nil {
+						p.Stages[ii].ID = uuid.New()
+						changed = true
+					}
+				}
+				if !changed {
+					return nil
+				}
+				v, err := json.Marshal(p)
+				if err != nil {
+					return fmt.Errorf("serializing project: %w", err)
+				}
+				updates[string(k)] = v
+				return nil
+			}); err != nil {
+				return fmt.Errorf("%s: %w", bucket, err)
+			}
+			for k, v := range updates {
+				if err := b.Put([]byte(k), v); err != nil {
+					return fmt.Errorf("%s: updating project: %w", bucket, err)
+				}
+			}
+		}
+		return nil
+	})
 }
 
 func (db *Storer) Create(p kanban.Project) error {
@@ -71,8 +130,23 @@ func (db *Storer) Create(p kanban.Project) error {
 // If a project is nil, or doesn't exist in the store, it will be zeroed out
 // in the slice.
 // The caller can then cleanup the slice by removing the zeroed out projects.
+//
+// Each project saved has its revision advanced in the slice. A project whose
+// revision differs from the stored revision, because it was saved elsewhere
+// since the caller loaded it, is left as is and a storage.ConflictError is
+// returned once the other projects are saved.
+//
+// Open locks the file, so another process cannot save over this one: it fails
+// to open the file with ErrLocked instead. A conflict comes from callers
+// sharing the Storer, such as the clients of a server, each saving from the
+// revision they loaded.
 func (db *Storer) Save(projects ...kanban.Project) error {
-	return db.Update(func(tx *bolt.Tx) error {
+	var (
+		conflict error
+		// revisions are applied to the slice once the transaction commits.
+		revisions = make(map[int]uint64)
+	)
+	if err := db.Update(func(tx *bolt.Tx) error {
 		for ii, p := range projects {
 			if p.ID == uuid.Nil {
 				continue
@@ -81,22 +155,43 @@ func (db *Storer) Save(projects ...kanban.Project) error {
 			if err != nil {
 				return fmt.Errorf("serializing project ID: %w", err)
 			}
-			v, err := json.Marshal(p)
-			if err != nil {
-				return fmt.Errorf("serializing project: %w", err)
-			}
 			if b := tx.Bucket(BucketProject); b != nil {
-				if b.Get(id) == nil {
+				existing := b.Get(id)
+				if existing == nil {
 					projects[ii] = kanban.Project{}
 					continue
 				}
+				var stored struct {
+					Revision uint64
+				}
+				if err := json.Unmarshal(existing, &stored); err != nil {
+					return fmt.Errorf("deserializing project: %w", err)
+				}
+				if stored.Revision != p.Revision {
+					if conflict == nil {
+						conflict = storage.ConflictError{ID: p.ID, Name: p.Name, Revision: p.Revision}
+					}
+					continue
+				}
+				p.Revision++
+				v, err := json.Marshal(p)
+				if err != nil {
+					return fmt.Errorf("serializing project: %w", err)
+				}
 				if err := b.Put(id, v); err != nil {
 					return fmt.Errorf("updating project: %w", err)
 				}
+				revisions[ii] = p.Revision
 			}
 		}
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
+	for ii, revision := range revisions {
+		projects[ii].Revision = revision
+	}
+	return conflict
 }
 
 func (db *Storer) Find(id uuid.UUID) (p kanban.Project, ok bool, err error) {
@@ -105,7 +200,11 @@ func (db *Storer) Find(id uuid.UUID) (p kanban.Project, ok bool, err error) {
 		return p, false, fmt.Errorf("serializing id: %w", err)
 	}
 	return p, ok, db.View(func(tx *bolt.Tx) error {
-		if err := json.Unmarshal(tx.Bucket(BucketProject).Get(key), &p); err != nil {
+		v := tx.Bucket(BucketProject).Get(key)
+		if v == nil {
+			return nil
+		}
+		if err := json.Unmarshal(v, &p); err != nil {
 			return fmt.Errorf("deserializing project: %w", err)
 		}
 		ok = true
@@ -164,6 +263,27 @@ func (db *Storer) ListArchived() ([]kanban.Project, error) {
 	return db.list(BucketArchive)
 }
 
+// Delete removes a project from whichever bucket holds it.
+func (db *Storer) Delete(id uuid.UUID) error {
+	k, err := id.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("serializing ID: %w", err)
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range []Bucket{BucketProject, BucketArchive} {
+			b := tx.Bucket(bucket)
+			if b.Get(k) == nil {
+				continue
+			}
+			if err := b.Delete(k); err != nil {
+				return fmt.Errorf("deleting project from %q bucket: %w", bucket, err)
+			}
+			return nil
+		}
+		return fmt.Errorf("project does not exist: %q", id)
+	})
+}
+
 func (db *Storer) move(id uuid.UUID, from, to Bucket) error {
 	k, err := id.MarshalBinary()
 	if err != nil {
@@ -0,0 +1,96 @@
+package bolt
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/storage"
+	"github.com/boltdb/bolt"
+	"github.com/google/uuid"
+)
+
+func TestSaveStaleRevision(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "kanban.db"))
+	if err != nil {
+		t.Fatalf("opening: %v", err)
+	}
+	defer s.Close()
+	p := kanban.Project{ID: uuid.New(), Name: "project"}
+	if err := s.Create(p); err != nil {
+		t.Fatalf("creating project: %v", err)
+	}
+	// Two callers load the same revision, and the first to save wins.
+	first := []kanban.Project{p}
+	if err := s.Save(first...); err != nil {
+		t.Fatalf("saving: %v", err)
+	}
+	if first[0].Revision != p.Revision+1 {
+		t.Fatalf("want revision advanced to %d, got %d", p.Revision+1, first[0].Revision)
+	}
+	p.Name = "stale"
+	err = s.Save(p)
+	var conflict storage.ConflictError
+	if !errors.As(err, &conflict) || conflict.ID != p.ID || !errors.Is(err, storage.ErrConflict) {
+		t.Fatalf("want ConflictError for a stale revision, got %v", err)
+	}
+	if got, _, _ := s.Find(p.ID); got.Name != "project" {
+		t.Fatalf("want stale save refused, got name %q", got.Name)
+	}
+}
+
+func TestMigrateStageIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kanban.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("opening: %v", err)
+	}
+	// Projects written before stages had IDs.
+	id := uuid.New()
+	key, _ := id.MarshalBinary()
+	v := []byte(`{"ID":"` + id.String() + `","Name":"project","Stages":[{"Name":"todo"},{"Name":"done"}]}`)
+	if err := s.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(BucketProject).Put(key, v)
+	}); err != nil {
+		t.Fatalf("writing project: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("closing: %v", err)
+	}
+	var stages kanban.Stages
+	for ii := 0; ii < 2; ii++ {
+		s, err := Open(path)
+		if err != nil {
+			t.Fatalf("reopening: %v", err)
+		}
+		p, ok, err := s.Find(id)
+		if err != nil || !ok {
+			t.Fatalf("finding project: %v, %v", ok, err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("closing: %v", err)
+		}
+		if p.Stages[0].ID == uuid.Nil || p.Stages[0].ID == p.Stages[1].ID {
+			t.Fatalf("want stages given unique IDs, got %v and %v", p.Stages[0].ID, p.Stages[1].ID)
+		}
+		if stages != nil && (p.Stages[0].ID != stages[0].ID || p.Stages[1].ID != stages[1].ID) {
+			t.Fatalf("want stage IDs stable across opens")
+		}
+		stages = p.Stages
+	}
+}
+
+func TestOpenLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kanban.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("opening: %v", err)
+	}
+	defer s.Close()
+	// Another instance of the app opening the same file is refused, rather
+	// than left to save over this one.
+	if _, err := Open(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("want ErrLocked opening a file in use, got %v", err)
+	}
+}
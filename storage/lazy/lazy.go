@@ -3,6 +3,7 @@
 package lazy
 
 import (
+	"errors"
 	"fmt"
 
 	"git.sr.ht/~jackmordaunt/kanban"
@@ -43,29 +44,79 @@ func Open(path string) (*Storer, error) {
 	return &s, nil
 }
 
+// Create a project, keeping the cache in sync with the disk.
+func (s *Storer) Create(p kanban.Project) error {
+	if err := s.Storer.Create(p); err != nil {
+		return err
+	}
+	s.Cache.Active.Add(p)
+	return nil
+}
+
 // Save a project. Only saves to disk if changed.
+// Projects missing from the cache are always saved, since there is nothing
+// to compare them to.
+//
+// Revisions are advanced in the provided slice just as the disk advances
+// them, and conflicts are reported as the disk reports them.
 func (s *Storer) Save(projects ...kanban.Project) error {
-	var save []kanban.Project
-	for _, p := range projects {
+	var (
+		save []kanban.Project
+		// from maps each project in save to its index in projects.
+		from []int
+	)
+	for ii, p := range projects {
 		old, ok, err := s.Cache.Find(p.ID)
 		if err != nil {
 			return err
 		}
-		if !ok {
-			s.Cache.Active.Add(p)
-			continue
-		}
-		if !p.Eq(&old) {
+		if !ok || !p.Eq(&old) {
 			save = append(save, p)
+			from = append(from, ii)
 		}
 	}
-	if len(save) > 0 {
-		if err := s.Storer.Save(save...); err != nil {
-			return fmt.Errorf("saving to disk: %w", err)
+	if len(save) == 0 {
+		return nil
+	}
+	conflict := s.Storer.Save(save...)
+	if conflict != nil && !errors.Is(conflict, storage.ErrConflict) {
+		return fmt.Errorf("saving to disk: %w", conflict)
+	}
+	for jj, ii := range from {
+		if save[jj].ID == uuid.Nil {
+			projects[ii] = kanban.Project{}
+			continue
 		}
-		return s.Populate()
+		projects[ii].Revision = save[jj].Revision
 	}
-	return nil
+	if err := s.Populate(); err != nil {
+		return err
+	}
+	return conflict
+}
+
+// Archive a project, keeping the cache in sync with the disk.
+func (s *Storer) Archive(id uuid.UUID) error {
+	if err := s.Storer.Archive(id); err != nil {
+		return err
+	}
+	return s.Populate()
+}
+
+// Restore an archived project, keeping the cache in sync with the disk.
+func (s *Storer) Restore(id uuid.UUID) error {
+	if err := s.Storer.Restore(id); err != nil {
+		return err
+	}
+	return s.Populate()
+}
+
+// Delete a project, keeping the cache in sync with the disk.
+func (s *Storer) Delete(id uuid.UUID) error {
+	if err := s.Storer.Delete(id); err != nil {
+		return err
+	}
+	return s.Populate()
 }
 
 // Refresh a project entity by loading from disk.
@@ -0,0 +1,50 @@
+package lazy
+
+import (
+	"path/filepath"
+	"testing"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"github.com/google/uuid"
+)
+
+func TestSaveDeletedFinalizedTicket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kanban.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("opening: %v", err)
+	}
+	p := kanban.Project{ID: uuid.New(), Name: "project"}
+	stage := p.MakeStage("todo")
+	ticket := kanban.Ticket{ID: uuid.New(), Title: "ticket"}
+	if err := p.AssignTicket(stage, ticket); err != nil {
+		t.Fatalf("assigning ticket: %v", err)
+	}
+	if err := p.FinalizeTicket(ticket); err != nil {
+		t.Fatalf("finalizing ticket: %v", err)
+	}
+	if err := s.Create(p); err != nil {
+		t.Fatalf("creating project: %v", err)
+	}
+	if err := p.DeleteTicket(ticket); err != nil {
+		t.Fatalf("deleting ticket: %v", err)
+	}
+	if err := s.Save(p); err != nil {
+		t.Fatalf("saving project: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("closing: %v", err)
+	}
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	defer s.Close()
+	got, ok, err := s.Find(p.ID)
+	if err != nil || !ok {
+		t.Fatalf("finding project: %v, %v", ok, err)
+	}
+	if len(got.Finalized) != 0 {
+		t.Fatalf("want no finalized tickets, got %d", len(got.Finalized))
+	}
+}
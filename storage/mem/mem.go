@@ -6,9 +6,10 @@ import (
 	"github.com/google/uuid"
 
 	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/storage"
 )
 
-// var _ storage.Storer = (*Storer)(nil)
+var _ storage.Storer = (*Storer)(nil)
 
 // Storer implements in-memory storage for Projects.
 type Storer struct {
@@ -40,15 +41,27 @@ func (s *Storer) Create(p kanban.Project) error {
 	return nil
 }
 
+// Save the projects, advancing their revisions in the slice.
+// Returns a storage.ConflictError for a project whose revision is out of date,
+// once the other projects are saved.
 func (s *Storer) Save(projects ...kanban.Project) error {
-	for _, p := range projects {
-		if _, ok := s.Active.Data[p.ID]; ok {
-			s.Active.Data[p.ID] = p.Clone()
-		} else {
+	var conflict error
+	for ii, p := range projects {
+		stored, ok := s.Active.Data[p.ID]
+		if !ok {
 			return fmt.Errorf("project %q does not exist", p.Name)
 		}
+		if stored.Revision != p.Revision {
+			if conflict == nil {
+				conflict = storage.ConflictError{ID: p.ID, Name: p.Name, Revision: p.Revision}
+			}
+			continue
+		}
+		p.Revision++
+		s.Active.Data[p.ID] = p.Clone()
+		projects[ii].Revision = p.Revision
 	}
-	return nil
+	return conflict
 }
 
 func (s *Storer) Find(id uuid.UUID) (p kanban.Project, ok bool, err error) {
@@ -91,6 +104,18 @@ func (s *Storer) Restore(id uuid.UUID) error {
 	return nil
 }
 
+func (s *Storer) Delete(id uuid.UUID) error {
+	if _, ok := s.Active.Data[id]; ok {
+		s.Active.Delete(id)
+		return nil
+	}
+	if _, ok := s.Archived.Data[id]; ok {
+		s.Archived.Delete(id)
+		return nil
+	}
+	return fmt.Errorf("project does not exist: %v", id)
+}
+
 func (s *Storer) ListArchived() (list []kanban.Project, err error) {
 	return s.Archived.List(), nil
 }
@@ -104,8 +129,10 @@ func (s *Storer) Clear() {
 	}
 }
 
+// Add a project to the bucket.
+// The project is cloned so that the caller's copy can be mutated freely.
 func (b *Bucket) Add(p kanban.Project) {
-	b.Data[p.ID] = p
+	b.Data[p.ID] = p.Clone()
 	b.Order = append(b.Order, p.ID)
 }
 
@@ -121,7 +148,7 @@ func (b *Bucket) Delete(id uuid.UUID) {
 
 func (b *Bucket) List() (list []kanban.Project) {
 	for _, id := range b.Order {
-		list = append(list, b.Data[id])
+		list = append(list, b.Data[id].Clone())
 	}
 	return list
 }
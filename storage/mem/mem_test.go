@@ -0,0 +1,35 @@
+package mem
+
+import (
+	"errors"
+	"testing"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/storage"
+	"github.com/google/uuid"
+)
+
+func TestSaveStaleRevision(t *testing.T) {
+	s := New()
+	p := kanban.Project{ID: uuid.New(), Name: "project"}
+	if err := s.Create(p); err != nil {
+		t.Fatalf("creating project: %v", err)
+	}
+	// Two callers load the same revision, and the first to save wins.
+	first := []kanban.Project{p}
+	if err := s.Save(first...); err != nil {
+		t.Fatalf("saving: %v", err)
+	}
+	if first[0].Revision != p.Revision+1 {
+		t.Fatalf("want revision advanced to %d, got %d", p.Revision+1, first[0].Revision)
+	}
+	p.Name = "stale"
+	err := s.Save(p)
+	var conflict storage.ConflictError
+	if !errors.As(err, &conflict) || conflict.ID != p.ID || !errors.Is(err, storage.ErrConflict) {
+		t.Fatalf("want ConflictError for a stale revision, got %v", err)
+	}
+	if got, _, _ := s.Find(p.ID); got.Name != "project" {
+		t.Fatalf("want stale save refused, got name %q", got.Name)
+	}
+}
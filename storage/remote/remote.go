@@ -0,0 +1,297 @@
+// Package remote implements storage over HTTP, against a kanban server such
+// as the one served by package server.
+//
+// Several people can point the app at one server to share projects. Each
+// save carries the revision the project was loaded at, and the server refuses
+// saves from out of date revisions with a storage.ConflictError, rather than
+// letting one person silently overwrite another.
+//
+// Changes made by others are picked up by Watch, which polls the server in
+// the background so that Load never waits on the network.
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/storage"
+	"github.com/google/uuid"
+)
+
+var _ storage.Storer = (*Storer)(nil)
+
+// Storer stores projects on a kanban server.
+//
+// Projects are cached as they were last seen on the server, so that only
+// changed projects are sent when saving.
+type Storer struct {
+	// URL of the server, such as "http://localhost:8080".
+	URL    string
+	Client *http.Client
+	cache  map[uuid.UUID]kanban.Project
+	// fetched hands the projects polled by Watch to Load.
+	fetched chan []kanban.Project
+}
+
+// New creates a storer for the server at url.
+func New(url string) *Storer {
+	return &Storer{
+		URL:     strings.TrimRight(url, "/"),
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		cache:   make(map[uuid.UUID]kanban.Project),
+		fetched: make(chan []kanban.Project, 1),
+	}
+}
+
+// Watch polls the server for changes every interval in a background
+// goroutine, until stop is closed.
+//
+// When the projects have changed since the last poll they are handed to the
+// next call to Load, and notify is called so that the caller knows to Load.
+func (s *Storer) Watch(interval time.Duration, notify func(), stop <-chan struct{}) {
+	if s.fetched == nil {
+		s.fetched = make(chan []kanban.Project, 1)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		revisions := make(map[uuid.UUID]uint64)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			var projects []kanban.Project
+			if err := s.do(http.MethodGet, "/projects", nil, &projects); err != nil {
+				continue
+			}
+			var changed bool
+			for _, p := range projects {
+				if rev, ok := revisions[p.ID]; !ok || rev != p.Revision {
+					revisions[p.ID] = p.Revision
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+			// Replace any projects not yet loaded, since these are newer.
+			select {
+			case <-s.fetched:
+			default:
+			}
+			s.fetched <- projects
+			if notify != nil {
+				notify()
+			}
+		}
+	}()
+}
+
+// StatusError is returned when the server responds with an unexpected status.
+type StatusError struct {
+	Method string
+	Path   string
+	Status int
+	// Message is the error reported by the server, if any.
+	Message string
+}
+
+func (err StatusError) Error() string {
+	msg := err.Message
+	if msg == "" {
+		msg = http.StatusText(err.Status)
+	}
+	return fmt.Sprintf("%s %s: %d: %s", err.Method, err.Path, err.Status, msg)
+}
+
+func (s *Storer) Create(p kanban.Project) error {
+	var created kanban.Project
+	if err := s.do(http.MethodPost, "/projects", p, &created); err != nil {
+		return err
+	}
+	s.remember(created)
+	return nil
+}
+
+// Save the projects that have changed since they were last seen on the server.
+//
+// Projects that no longer exist on the server are zeroed out in the slice,
+// as bolt.Storer does. Projects are saved independently: a conflict on one
+// does not stop the others from being saved, and the first conflict is
+// returned once they all have been tried.
+func (s *Storer) Save(projects ...kanban.Project) error {
+	var conflict error
+	for ii, p := range projects {
+		if p.ID == uuid.Nil {
+			continue
+		}
+		if old, ok := s.cache[p.ID]; ok && p.Eq(&old) {
+			continue
+		}
+		var saved kanban.Project
+		err := s.do(http.MethodPut, "/projects/"+p.ID.String(), p, &saved)
+		if status, ok := err.(StatusError); ok {
+			switch status.Status {
+			case http.StatusNotFound:
+				delete(s.cache, p.ID)
+				projects[ii] = kanban.Project{}
+				continue
+			case http.StatusPreconditionFailed:
+				if conflict == nil {
+					conflict = storage.ConflictError{ID: p.ID, Name: p.Name, Revision: p.Revision}
+				}
+				continue
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("saving %q: %w", p.Name, err)
+		}
+		projects[ii].Revision = saved.Revision
+		s.remember(saved)
+	}
+	return conflict
+}
+
+// Load updates the projects with any newer revisions fetched by Watch,
+// without touching the network.
+// Projects yet to be loaded, having no ID, are listed from the server.
+//
+// Projects with changes not yet saved are left as they are, rather than
+// discarding the changes. Saving them then fails with a storage.ConflictError,
+// just as it would had they been saved before the newer revision arrived.
+func (s *Storer) Load(projects []kanban.Project) error {
+	if len(projects) > 0 && projects[0].ID == uuid.Nil {
+		list, err := s.List()
+		if err != nil {
+			return err
+		}
+		copy(projects, list)
+		return nil
+	}
+	var fetched []kanban.Project
+	select {
+	case fetched = <-s.fetched:
+	default:
+		return nil
+	}
+	for _, f := range fetched {
+		for ii, p := range projects {
+			// Older revisions were fetched before the latest save.
+			if p.ID != f.ID || p.Revision >= f.Revision {
+				continue
+			}
+			if old, ok := s.cache[p.ID]; !ok || p.Eq(&old) {
+				projects[ii] = f
+				s.remember(f)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Storer) Find(id uuid.UUID) (kanban.Project, bool, error) {
+	var p kanban.Project
+	err := s.do(http.MethodGet, "/projects/"+id.String(), nil, &p)
+	if status, ok := err.(StatusError); ok && status.Status == http.StatusNotFound {
+		return p, false, nil
+	}
+	if err != nil {
+		return p, false, err
+	}
+	s.remember(p)
+	return p, true, nil
+}
+
+func (s *Storer) List() ([]kanban.Project, error) {
+	var projects []kanban.Project
+	if err := s.do(http.MethodGet, "/projects", nil, &projects); err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		s.remember(p)
+	}
+	return projects, nil
+}
+
+func (s *Storer) Count() (int, error) {
+	projects, err := s.List()
+	return len(projects), err
+}
+
+func (s *Storer) Archive(id uuid.UUID) error {
+	return s.do(http.MethodPost, "/projects/"+id.String()+"/archive", nil, nil)
+}
+
+func (s *Storer) ListArchived() ([]kanban.Project, error) {
+	var projects []kanban.Project
+	return projects, s.do(http.MethodGet, "/archived", nil, &projects)
+}
+
+func (s *Storer) Restore(id uuid.UUID) error {
+	return s.do(http.MethodPost, "/projects/"+id.String()+"/restore", nil, nil)
+}
+
+func (s *Storer) Delete(id uuid.UUID) error {
+	delete(s.cache, id)
+	return s.do(http.MethodDelete, "/projects/"+id.String(), nil, nil)
+}
+
+// remember a project as it was seen on the server.
+func (s *Storer) remember(p kanban.Project) {
+	if s.cache == nil {
+		s.cache = make(map[uuid.UUID]kanban.Project)
+	}
+	s.cache[p.ID] = p.Clone()
+}
+
+// do a request against the server, encoding body as JSON if not nil and
+// decoding the response into v if not nil.
+// Returns a StatusError for any unsuccessful response.
+func (s *Storer) do(method, path string, body, v interface{}) error {
+	var r io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		r = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequest(method, s.URL+path, r)
+	if err != nil {
+		return fmt.Errorf("preparing request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return StatusError{Method: method, Path: path, Status: resp.StatusCode, Message: e.Error}
+	}
+	if v == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s %s: decoding response: %w", method, path, err)
+	}
+	return nil
+}
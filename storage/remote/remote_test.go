@@ -0,0 +1,199 @@
+package remote
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.sr.ht/~jackmordaunt/kanban"
+	"git.sr.ht/~jackmordaunt/kanban/server"
+	"git.sr.ht/~jackmordaunt/kanban/storage"
+	"git.sr.ht/~jackmordaunt/kanban/storage/mem"
+	"github.com/google/uuid"
+)
+
+// setup serves a kanban server holding a single project, and returns its URL
+// along with a count of the requests it has handled.
+func setup(t *testing.T) (string, *int64, kanban.Project) {
+	t.Helper()
+	var (
+		requests int64
+		api      = server.New(mem.New())
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	p := kanban.Project{ID: uuid.New(), Name: "project"}
+	p.MakeStage("todo")
+	if err := New(srv.URL).Create(p); err != nil {
+		t.Fatalf("creating project: %v", err)
+	}
+	return srv.URL, &requests, p
+}
+
+// load the projects from the server as the app does on startup.
+func load(t *testing.T, s *Storer) []kanban.Project {
+	t.Helper()
+	count, err := s.Count()
+	if err != nil {
+		t.Fatalf("counting projects: %v", err)
+	}
+	projects := make([]kanban.Project, count)
+	if err := s.Load(projects); err != nil {
+		t.Fatalf("loading projects: %v", err)
+	}
+	return projects
+}
+
+// watch s until the test ends, returning a channel that receives when the
+// projects on the server have changed.
+func watch(t *testing.T, s *Storer) <-chan struct{} {
+	var (
+		stop    = make(chan struct{})
+		changed = make(chan struct{}, 1)
+	)
+	t.Cleanup(func() { close(stop) })
+	s.Watch(10*time.Millisecond, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}, stop)
+	return changed
+}
+
+// wait for a change to be noticed, failing the test if it never is.
+func wait(t *testing.T, changed <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("want change noticed")
+	}
+}
+
+func TestSave(t *testing.T) {
+	url, requests, p := setup(t)
+	s := New(url)
+	projects := load(t, s)
+	if len(projects) != 1 || projects[0].ID != p.ID {
+		t.Fatalf("want created project loaded, got %d projects", len(projects))
+	}
+	projects[0].Name = "renamed"
+	if err := s.Save(projects...); err != nil {
+		t.Fatalf("saving: %v", err)
+	}
+	if projects[0].Revision != p.Revision+1 {
+		t.Fatalf("want revision advanced to %d, got %d", p.Revision+1, projects[0].Revision)
+	}
+	// Saving without changes does not touch the server.
+	before := atomic.LoadInt64(requests)
+	if err := s.Save(projects...); err != nil {
+		t.Fatalf("saving: %v", err)
+	}
+	if after := atomic.LoadInt64(requests); after != before {
+		t.Fatalf("want unchanged project not sent, got %d requests", after-before)
+	}
+	got, ok, err := New(url).Find(p.ID)
+	if err != nil || !ok {
+		t.Fatalf("finding project: %v, %v", ok, err)
+	}
+	if got.Name != "renamed" || got.Revision != projects[0].Revision {
+		t.Fatalf("want saved project on the server, got %q at %d", got.Name, got.Revision)
+	}
+}
+
+func TestSaveStaleRevision(t *testing.T) {
+	url, _, p := setup(t)
+	a, b := New(url), New(url)
+	mine, theirs := load(t, a), load(t, b)
+	theirs[0].Name = "theirs"
+	if err := b.Save(theirs...); err != nil {
+		t.Fatalf("saving: %v", err)
+	}
+	mine[0].Name = "mine"
+	err := a.Save(mine...)
+	var conflict storage.ConflictError
+	if !errors.As(err, &conflict) || conflict.ID != p.ID || !errors.Is(err, storage.ErrConflict) {
+		t.Fatalf("want ConflictError for a stale revision, got %v", err)
+	}
+	if got, _, _ := b.Find(p.ID); got.Name != "theirs" {
+		t.Fatalf("want stale save refused, got name %q", got.Name)
+	}
+}
+
+func TestSaveDeleted(t *testing.T) {
+	url, _, p := setup(t)
+	s := New(url)
+	projects := load(t, s)
+	if err := New(url).Delete(p.ID); err != nil {
+		t.Fatalf("deleting project: %v", err)
+	}
+	projects[0].Name = "renamed"
+	if err := s.Save(projects...); err != nil {
+		t.Fatalf("saving: %v", err)
+	}
+	if projects[0].ID != uuid.Nil {
+		t.Fatalf("want deleted project zeroed out")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	url, requests, _ := setup(t)
+	a, b := New(url), New(url)
+	mine, theirs := load(t, a), load(t, b)
+	// Nothing has been fetched yet, so loading does not wait on the network.
+	before := atomic.LoadInt64(requests)
+	if err := a.Load(mine); err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	if after := atomic.LoadInt64(requests); after != before {
+		t.Fatalf("want load served without the network, got %d requests", after-before)
+	}
+	changed := watch(t, a)
+	wait(t, changed)
+	theirs[0].Name = "theirs"
+	if err := b.Save(theirs...); err != nil {
+		t.Fatalf("saving: %v", err)
+	}
+	wait(t, changed)
+	if err := a.Load(mine); err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	if mine[0].Name != "theirs" || mine[0].Revision != theirs[0].Revision {
+		t.Fatalf("want newer revision loaded, got %q at %d", mine[0].Name, mine[0].Revision)
+	}
+	// Having loaded the latest revision, saving on top of it succeeds.
+	mine[0].Name = "mine"
+	if err := a.Save(mine...); err != nil {
+		t.Fatalf("saving over the loaded revision: %v", err)
+	}
+}
+
+func TestLoadKeepsUnsaved(t *testing.T) {
+	url, _, _ := setup(t)
+	a, b := New(url), New(url)
+	mine, theirs := load(t, a), load(t, b)
+	changed := watch(t, a)
+	wait(t, changed)
+	mine[0].Name = "mine"
+	theirs[0].Name = "theirs"
+	if err := b.Save(theirs...); err != nil {
+		t.Fatalf("saving: %v", err)
+	}
+	wait(t, changed)
+	if err := a.Load(mine); err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	if mine[0].Name != "mine" {
+		t.Fatalf("want unsaved change kept, got %q", mine[0].Name)
+	}
+	if err := a.Save(mine...); !errors.Is(err, storage.ErrConflict) {
+		t.Fatalf("want unsaved change refused as a conflict, got %v", err)
+	}
+}
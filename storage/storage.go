@@ -3,15 +3,43 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
+
 	"git.sr.ht/~jackmordaunt/kanban"
 	"github.com/google/uuid"
 )
 
+// ErrConflict matches any ConflictError, for use with errors.Is.
+var ErrConflict = errors.New("project was changed elsewhere")
+
+// ConflictError is returned by Save when a project was saved by someone else
+// since the caller loaded it, such that saving would overwrite their changes.
+//
+// The caller is expected to load the project again and retry.
+type ConflictError struct {
+	ID   uuid.UUID
+	Name string
+	// Revision is the revision the caller tried to save over.
+	Revision uint64
+}
+
+func (err ConflictError) Error() string {
+	return fmt.Sprintf("project %q was changed elsewhere since revision %d", err.Name, err.Revision)
+}
+
+func (err ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
 // Storer persists Project entities.
 type Storer interface {
 	// Create a new Project.
 	Create(kanban.Project) error
 	// Save one or more existing Projects, updating the storage device.
+	// Storers that track revisions return a ConflictError for a project whose
+	// revision is out of date, and advance the revision of each project
+	// saved, in place, so that callers keep saving from the latest revision.
 	Save(...kanban.Project) error
 	// Load updates the Projects using data from the storage device.
 	// Allows caller to allocate and control memory.
@@ -31,4 +59,6 @@ type Storer interface {
 	ListArchived() ([]kanban.Project, error)
 	// Restore takes an archived project and makes it live again.
 	Restore(uuid.UUID) error
+	// Delete a project permanently, whether it is active or archived.
+	Delete(uuid.UUID) error
 }